@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/builder"
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+	"github.com/shouni/web-text-pipe-go/pkg/urllist"
+
+	"github.com/shouni/go-web-exact/v2/pkg/scraper"
+	"github.com/spf13/cobra"
+)
+
+// --- サブコマンド定義 ---
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "ファイルまたは標準入力のURLリストから、Webコンテンツを並列で取得・整形します",
+	Long: `--input で指定されたファイル (省略時または "-" の場合は標準入力) から、改行区切りのURL、
+または {"url": "...", "title": "..."} 形式の JSON Lines を読み込み、scraper コマンドと同じ
+リトライ戦略を持つ並列スクレイピングでコンテンツ抽出を実行します。`,
+	Args: cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 1. フラグ値の取得と設定の構築
+		inputPath, _ := cmd.Flags().GetString("input")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		clientTimeout := time.Duration(Flags.TimeoutSec) * time.Second
+
+		outputOpts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		// 2. URLリストの読み込みとバリデーション
+		items, err := urllist.Load(inputPath)
+		if err != nil {
+			return fmt.Errorf("エラー: URLリストの読み込みに失敗しました: %w", err)
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("エラー: 処理対象のURLが一つもありません (入力: %s)", inputPath)
+		}
+
+		urls := make([]string, 0, len(items))
+		titlesMap := make(map[string]string, len(items))
+		for _, item := range items {
+			urls = append(urls, item.URL)
+			if item.Title != "" {
+				titlesMap[item.URL] = item.Title
+			}
+		}
+
+		stopTracing, err := startTracing()
+		if err != nil {
+			return err
+		}
+		defer stopTracing()
+
+		// 3. ScraperExecutor を取得
+		hostLimits, err := hostLimitsFromFlags()
+		if err != nil {
+			return err
+		}
+		httpClient, err := newHTTPClient(clientTimeout)
+		if err != nil {
+			return err
+		}
+		pipe, err := pipelineFromFlags()
+		if err != nil {
+			return err
+		}
+		defer closePipeline(pipe)
+		m, pushMetrics := newPushgatewayMetrics(cmd)
+		defer pushMetrics()
+		executor, err := builder.BuildReliableScraperExecutor(builder.Options{
+			ClientTimeout:   clientTimeout,
+			HTTPClient:      httpClient,
+			Concurrency:     concurrency,
+			RetryPolicy:     retryPolicyFromFlags(),
+			HostLimits:      hostLimits,
+			RobotsUserAgent: Flags.RobotsUserAgent,
+			IgnoreRobots:    Flags.IgnoreRobots,
+			Normalizer:      normalizerFromFlags(),
+			Metrics:         m,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 4. 実行コンテキストの準備
+		// URLの件数に上限がないため全体タイムアウトは設けず、Ctrl-C (SIGINT) / SIGTERM でのみ中断する
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		log.Printf("バッチ処理開始 (URL: %d 件, 入力: %s)\n", len(urls), inputPath)
+
+		// 5. ストリーミングでのスクレイピングの実行
+		// 記事の結果は、すべての記事の完了を待たずに確定した順に後処理を適用して出力する
+		var processor runner.RecordProcessor
+		if pipe != nil {
+			processor = pipe
+		}
+		writer := newResultWriter(outputOpts)
+		defer writer.Close()
+		startedAt := time.Now()
+		runnerResult := &runner.RunnerResult{TitlesMap: titlesMap}
+		runner.StreamResults(ctx, executor, processor, runnerResult, urls, writer.Write)
+		runnerResult.ScrapeDuration = time.Since(startedAt)
+
+		// 6. 結果の出力の完了
+		if err := writer.Finish(runnerResult); err != nil {
+			return err
+		}
+		return finishRun(cmd, outputOpts, runnerResult, nil, startedAt)
+	},
+}
+
+// --- フラグ初期化 ---
+
+func initBatchFlags() {
+	batchCmd.Flags().StringP("input", "i", urllist.StdinPath, "URLリストのファイルパス。\"-\" の場合は標準入力から読み込みます")
+	batchCmd.Flags().IntP("concurrency", "c", scraper.DefaultMaxConcurrency, "最大並列実行数 (デフォルト: 10)")
+	addOutputFlags(batchCmd)
+	addPushgatewayFlags(batchCmd)
+}
@@ -2,12 +2,19 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/url"
 	"time"
 
-	"github.com/shouni/go-http-kit/pkg/httpkit"
+	"github.com/shouni/web-text-pipe-go/pkg/article"
+	"github.com/shouni/web-text-pipe-go/pkg/builder"
+	"github.com/shouni/web-text-pipe-go/pkg/charset"
+	"github.com/shouni/web-text-pipe-go/pkg/normalize"
+	"github.com/shouni/web-text-pipe-go/pkg/pipeline"
+	"github.com/shouni/web-text-pipe-go/pkg/urllist"
+
 	iohandler "github.com/shouni/go-utils/iohandler"
 	"github.com/shouni/go-web-exact/v2/pkg/extract"
 	"github.com/spf13/cobra"
@@ -16,10 +23,14 @@ import (
 // --- メインロジック ---
 
 // runExactExtraction は、単一URLからの抽出を実行するロジックです。
-func runExactExtraction(ctx context.Context, fetcher extract.Fetcher, url string) (text string, isBodyExtracted bool, err error) {
+// normalizer・pipe が nil でない場合は、本文を抽出できたテキストを正規化し、後処理のステージを適用します。
+// 後処理のステージで除外された場合は、pipeline.ErrDropped をラップしたエラーを返します。
+func runExactExtraction(ctx context.Context, fetcher extract.Fetcher, normalizer *normalize.Normalizer, pipe *pipeline.Pipeline, url string) (text string, isBodyExtracted bool, err error) {
 	// 1. Extractor の初期化
-	// Extractor は内部で extract.Fetcher に依存するため、引数として受け取った fetcher をそのまま渡す。
-	extractor, err := extract.NewExtractor(fetcher)
+	// Extractor は内部で extract.Fetcher に依存するため、取得したHTMLを UTF-8 に変換する Fetcher でラップして渡す。
+	// 判定した文字コードとページのメタデータは、ctx に charset.WithRecorder・article.WithRecorder で
+	// 記録先が設定されている場合に記録される。
+	extractor, err := extract.NewExtractor(article.NewFetcher(charset.NewFetcher(fetcher)))
 	if err != nil {
 		return "", false, fmt.Errorf("Extractorの初期化エラー: %w", err)
 	}
@@ -31,7 +42,31 @@ func runExactExtraction(ctx context.Context, fetcher extract.Fetcher, url string
 		return "", false, fmt.Errorf("コンテンツ抽出エラー (URL: %s): %w", url, err)
 	}
 
-	return text, isBodyExtracted, nil
+	if !isBodyExtracted {
+		return text, false, nil
+	}
+
+	// 3. 正規化と後処理 (scraper の出力と同じ処理を適用する)
+	record, err := pipe.Process(ctx, pipeline.Record{URL: url, Content: normalizer.Normalize(text)})
+	if err != nil {
+		return "", false, fmt.Errorf("後処理エラー (URL: %s): %w", url, err)
+	}
+
+	return record.Content, true, nil
+}
+
+// logPageMetadata は、ページのメタデータを JSON でログに出力します (標準出力には本文のみを出力するため)。
+func logPageMetadata(page *article.Page) {
+	if page == nil {
+		log.Println("ページのメタデータ: なし")
+		return
+	}
+	data, err := json.Marshal(page)
+	if err != nil {
+		log.Printf("⚠️ ページのメタデータのJSONエンコードエラー: %v\n", err)
+		return
+	}
+	log.Printf("ページのメタデータ: %s\n", data)
 }
 
 // --- サブコマンド定義 ---
@@ -56,30 +91,54 @@ var exactCmd = &cobra.Command{
 		if rawURL == "" {
 			return fmt.Errorf("エラー: 抽出対象のURL (--url, -u) は必須です")
 		}
-		parsedURL, err := url.Parse(rawURL)
-		if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
-			return fmt.Errorf("エラー: 無効なURL形式です。有効なスキームとホストを含むURLを指定してください: %w", err)
+		if err := urllist.Validate(rawURL); err != nil {
+			return fmt.Errorf("エラー: %w", err)
 		}
 
+		stopTracing, err := startTracing()
+		if err != nil {
+			return err
+		}
+		defer stopTracing()
+
 		// 2. HTTPクライアントの初期化 (root.go のグローバルフラグを使用)
 		clientTimeout := time.Duration(Flags.TimeoutSec) * time.Second
-		// httpkit.New の戻り値は *httpkit.Client であり、これが extract.Fetcher インターフェースを満たす。
-		fetcher := httpkit.New(clientTimeout)
+		// scraper と同じ HTTP 設定 (User-Agent・ヘッダー・Cookie) を適用したクライアントを使用する。
+		httpClient, err := newHTTPClient(clientTimeout)
+		if err != nil {
+			return err
+		}
+		// builder.BuildFetcher は、取得ごとのスパンを記録する extract.Fetcher を返す。
+		fetcher := builder.BuildFetcher(builder.Options{ClientTimeout: clientTimeout, HTTPClient: httpClient})
+		pipe, err := pipelineFromFlags()
+		if err != nil {
+			return err
+		}
+		defer closePipeline(pipe)
 
 		// 3. 全体実行コンテキストの設定
 		// 単一抽出のため、HTTPクライアントのタイムアウトとコマンド全体のタイムアウトを同じ値とする。
 		// これにより、HTTPリクエストがタイムアウトした場合、直ちにコマンド全体も終了する。
 		ctx, cancel := context.WithTimeout(context.Background(), clientTimeout)
 		defer cancel()
+		charsets := charset.NewRecorder()
+		ctx = charset.WithRecorder(ctx, charsets)
+		pages := article.NewRecorder()
+		ctx = article.WithRecorder(ctx, pages)
 
 		log.Printf("抽出処理開始 (URL: %s, タイムアウト: %s)\n", rawURL, clientTimeout)
 
 		// 4. メインロジックの実行
-		// fetcher (*httpkit.Client) は runExactExtraction が要求する extract.Fetcher インターフェースを満たすため、型変換なしで渡せる。
-		text, isBodyExtracted, err := runExactExtraction(ctx, fetcher, rawURL)
+		text, isBodyExtracted, err := runExactExtraction(ctx, fetcher, normalizerFromFlags(), pipe, rawURL)
+		if errors.Is(err, pipeline.ErrDropped) {
+			log.Printf("--- 後処理で除外されました ---\n%v\n", err)
+			return nil
+		}
 		if err != nil {
 			return fmt.Errorf("コンテンツ抽出パイプラインの実行エラー: %w", err)
 		}
+		log.Printf("ページの文字コード: %s\n", charsets.Charset(rawURL))
+		logPageMetadata(pages.Page(rawURL))
 
 		// 5. 結果の出力
 		if !isBodyExtracted {
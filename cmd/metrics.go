@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/metrics"
+
+	"github.com/spf13/cobra"
+)
+
+const pushTimeout = 10 * time.Second // Pushgateway への送信のタイムアウト
+
+// --- ロジック: メトリクスの公開と送信 ---
+
+// addPushgatewayFlags は、一回限りの実行 (scraper / batch) のメトリクスを Pushgateway に送信するフラグを追加します。
+func addPushgatewayFlags(cmd *cobra.Command) {
+	cmd.Flags().String("pushgateway", "", "実行終了時にメトリクスを送信する Pushgateway のURL (例: http://localhost:9091)")
+	cmd.Flags().String("pushgateway-job", appName, "Pushgateway に送信する際のジョブ名")
+}
+
+// newPushgatewayMetrics は、--pushgateway が指定されている場合に Metrics と、実行終了時に呼び出す送信関数を返します。
+// 指定されていない場合は nil と何もしない関数を返します。送信の失敗はログに記録し、コマンドの結果には影響させません。
+func newPushgatewayMetrics(cmd *cobra.Command) (*metrics.Metrics, func()) {
+	gatewayURL, _ := cmd.Flags().GetString("pushgateway")
+	job, _ := cmd.Flags().GetString("pushgateway-job")
+	if gatewayURL == "" {
+		return nil, func() {}
+	}
+
+	m := metrics.New(false)
+	return m, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+		defer cancel()
+		if err := m.Push(ctx, gatewayURL, job); err != nil {
+			log.Printf("⚠️ Pushgateway へのメトリクス送信に失敗しました (URL: %s): %v\n", gatewayURL, err)
+		}
+	}
+}
+
+// serveMetrics は、ctx がキャンセルされるまで addr で /metrics を公開します。
+func serveMetrics(ctx context.Context, addr string, m *metrics.Metrics) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("メトリクスを公開します (http://%s/metrics)\n", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("⚠️ メトリクスサーバーの実行エラー: %v\n", err)
+	}
+}
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/output"
+	"github.com/shouni/web-text-pipe-go/pkg/pipeline"
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+
+	"github.com/shouni/go-cli-base"
+	"github.com/spf13/cobra"
+)
+
+// --- ロジック: 結果の出力 (I/O) ---
+
+// outputOptions は、scraper / batch コマンドで共通の出力設定を保持します。
+type outputOptions struct {
+	Format     string // --format 出力形式
+	OutputFile string // --output jsonl 形式の出力先ファイル
+	OutputDir  string // --output-dir 1記事1ファイルの出力先ディレクトリ
+
+	ReportFile    string  // --report 実行レポート (JSON) の出力先ファイル
+	FailThreshold float64 // --fail-threshold 非ゼロで終了する記事の失敗率の閾値
+}
+
+// addOutputFlags は、結果の出力に関するフラグをサブコマンドに追加します。
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("format", "f", output.FormatText, "出力形式 (text: 人間向けのサマリー, jsonl: 1記事1行のJSON Lines)")
+	cmd.Flags().StringP("output", "o", "", "jsonl 形式の出力先ファイル名。省略時は標準出力に出力。")
+	cmd.Flags().String("output-dir", "", "成功した記事を1記事1ファイルで保存するディレクトリ (index.json を併せて出力)")
+	cmd.Flags().String("report", "", "件数・ホスト別の失敗数・所要時間・リトライ統計をまとめた実行レポート (JSON) の出力先ファイル")
+	cmd.Flags().Float64("fail-threshold", 1, "記事の失敗率がこの値を超えた場合に非ゼロで終了します (0 なら1件でも失敗すると非ゼロ)")
+}
+
+// getOutputOptions は、フラグ値から出力設定を取得し、検証します。
+func getOutputOptions(cmd *cobra.Command) (outputOptions, error) {
+	var opts outputOptions
+	opts.Format, _ = cmd.Flags().GetString("format")
+	opts.OutputFile, _ = cmd.Flags().GetString("output")
+	opts.OutputDir, _ = cmd.Flags().GetString("output-dir")
+	opts.ReportFile, _ = cmd.Flags().GetString("report")
+	opts.FailThreshold, _ = cmd.Flags().GetFloat64("fail-threshold")
+
+	if opts.Format != output.FormatText && opts.Format != output.FormatJSONL {
+		return opts, fmt.Errorf("エラー: 無効な出力形式です (--format: %s)。%s または %s を指定してください", opts.Format, output.FormatText, output.FormatJSONL)
+	}
+	if opts.FailThreshold < 0 || opts.FailThreshold > 1 {
+		return opts, fmt.Errorf("エラー: --fail-threshold には 0 から 1 の範囲の値を指定してください (指定値: %g)", opts.FailThreshold)
+	}
+	return opts, nil
+}
+
+// finishRun は、--report が指定されている場合に実行レポートを出力し、実行結果に応じたエラーを返します。
+// runErr が nil でない場合はそれを返し、記事の失敗率が --fail-threshold を超えた場合はエラーを返して
+// プロセスを非ゼロで終了させます (CI やアラートでの利用向け)。
+func finishRun(cmd *cobra.Command, opts outputOptions, runnerResult *runner.RunnerResult, runErr error, startedAt time.Time) error {
+	report := output.NewReport(runnerResult, runErr, startedAt, time.Now())
+	if opts.ReportFile != "" {
+		if err := output.WriteReport(opts.ReportFile, report); err != nil {
+			return errors.Join(runErr, err)
+		}
+		log.Printf("実行レポートを出力しました (出力先: %s)\n", opts.ReportFile)
+	}
+	if runErr != nil {
+		return runErr
+	}
+
+	if report.FailureRatio > opts.FailThreshold {
+		// 閾値の超過は使い方の誤りではないため、ヘルプを表示しない
+		cmd.SilenceUsage = true
+		return fmt.Errorf("記事の失敗率が閾値を超えました (失敗: %d / %d 件, 失敗率: %.2f, --fail-threshold: %g)",
+			report.Failed, report.Total, report.FailureRatio, opts.FailThreshold)
+	}
+	return nil
+}
+
+// resultWriter は、出力設定に従って、記事の結果を確定した順に出力します (runner.ResultFunc として使用)。
+// 書き込みエラーは最初のものを保持し、Finish で返します。
+type resultWriter struct {
+	opts    outputOptions
+	printer *resultPrinter
+
+	dir   *output.DirWriter
+	jsonl *output.JSONLWriter
+	file  *os.File  // jsonl 形式の出力先ファイル (標準出力の場合は nil)
+	out   io.Writer // 設定されている場合、jsonl 形式の出力先 (OutputFile より優先し、Close でも閉じない)
+
+	err error
+}
+
+// newResultWriter は、出力設定から resultWriter を初期化します。
+// 出力先のファイル・ディレクトリは、最初の結果の出力時 (または Finish) に作成します。
+func newResultWriter(opts outputOptions) *resultWriter {
+	w := &resultWriter{opts: opts}
+	if opts.Format == output.FormatText {
+		w.printer = newResultPrinter(clibase.Flags.Verbose)
+	}
+	return w
+}
+
+// Write は、1件の結果を出力します。runner.ResultFunc のシグネチャです。
+func (w *resultWriter) Write(runnerResult *runner.RunnerResult, res runner.ScrapeResult) {
+	if w.err != nil {
+		return
+	}
+	if w.printer != nil {
+		w.printer.print(res)
+	}
+	if w.opts.OutputDir != "" {
+		if w.err = w.openDir(); w.err != nil {
+			return
+		}
+		if err := w.dir.Write(runnerResult, res); err != nil {
+			w.err = fmt.Errorf("記事ファイルの出力エラー: %w", err)
+			return
+		}
+	}
+	if w.opts.Format == output.FormatJSONL {
+		if w.err = w.openJSONL(); w.err != nil {
+			return
+		}
+		if err := w.jsonl.Write(output.NewRecord(runnerResult, res)); err != nil {
+			w.err = fmt.Errorf("JSON Lines 出力の生成エラー: %w", err)
+		}
+	}
+}
+
+// Finish は、出力を完了し (index.json の保存、サマリーの表示)、出力中に発生したエラーを返します。
+func (w *resultWriter) Finish(runnerResult *runner.RunnerResult) error {
+	defer w.Close()
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.opts.OutputDir != "" {
+		if err := w.openDir(); err != nil {
+			return err
+		}
+		manifest, err := w.dir.Finish(runnerResult)
+		if err != nil {
+			return fmt.Errorf("記事ファイルの出力エラー: %w", err)
+		}
+		log.Printf("記事ファイルを出力しました: %d 件 (出力先: %s)\n", len(manifest.Articles), w.opts.OutputDir)
+	}
+
+	if w.opts.Format == output.FormatJSONL {
+		// 結果が0件の場合も出力先のファイルを作成する
+		if err := w.openJSONL(); err != nil {
+			return err
+		}
+		// 標準出力を汚さないよう、サマリーはログ (標準エラー) にのみ出力する
+		log.Printf("JSON Lines 出力: %d 件 (フィードタイトル: %s)\n", w.jsonl.Count(), runnerResult.FeedTitle)
+		return nil
+	}
+
+	w.printer.summary()
+	return nil
+}
+
+// Close は、jsonl 形式の出力先ファイルを閉じます。Finish を呼び出さずに終了する場合も defer で呼び出してください。
+func (w *resultWriter) Close() {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			log.Printf("⚠️ 出力ファイルのクローズエラー (%s): %v\n", w.opts.OutputFile, err)
+		}
+		w.file = nil
+	}
+}
+
+// openDir は、1記事1ファイルの出力先ディレクトリを作成します (作成済みの場合は何もしません)。
+func (w *resultWriter) openDir() error {
+	if w.dir != nil {
+		return nil
+	}
+	dir, err := output.NewDirWriter(w.opts.OutputDir)
+	if err != nil {
+		return fmt.Errorf("記事ファイルの出力エラー: %w", err)
+	}
+	w.dir = dir
+	return nil
+}
+
+// openJSONL は、jsonl 形式の出力先を開きます (開いている場合は何もしません)。
+// 出力先ファイルが指定されていない場合は標準出力に出力します。
+func (w *resultWriter) openJSONL() error {
+	if w.jsonl != nil {
+		return nil
+	}
+	if w.out != nil {
+		w.jsonl = output.NewJSONLWriter(w.out)
+		return nil
+	}
+	if w.opts.OutputFile == "" {
+		w.jsonl = output.NewJSONLWriter(os.Stdout)
+		return nil
+	}
+	f, err := os.Create(w.opts.OutputFile)
+	if err != nil {
+		return fmt.Errorf("出力ファイルのオープンエラー (%s): %w", w.opts.OutputFile, err)
+	}
+	w.file = f
+	w.jsonl = output.NewJSONLWriter(f)
+	return nil
+}
+
+// resultPrinter は、結果を1件ずつ人間向けの形式で出力し、最後にサマリーを出力します。
+type resultPrinter struct {
+	verbose bool
+	started bool // ヘッダーを出力済みかどうか
+
+	count           int
+	successCount    int
+	errorCount      int
+	disallowedCount int
+	droppedCount    int
+}
+
+func newResultPrinter(verbose bool) *resultPrinter {
+	return &resultPrinter{verbose: verbose}
+}
+
+// header は、最初の出力の前に一度だけヘッダーを出力します。
+func (p *resultPrinter) header() {
+	if !p.started {
+		p.started = true
+		fmt.Println("\n--- 並列スクレイピング結果 ---")
+	}
+}
+
+// print は、1件の結果を出力します。
+func (p *resultPrinter) print(res runner.ScrapeResult) {
+	p.header()
+	p.count++
+	i := p.count
+	if errors.Is(res.Error, runner.ErrDisallowedByRobots) {
+		p.disallowedCount++
+		log.Printf("🚫 [%d] %s\n     robots.txt により取得が許可されていないためスキップしました\n", i, res.URL)
+	} else if errors.Is(res.Error, pipeline.ErrDropped) {
+		p.droppedCount++
+		log.Printf("🗑️ [%d] %s\n     %v\n", i, res.URL, res.Error)
+	} else if res.Error != nil {
+		p.errorCount++
+		log.Printf("❌ [%d] %s\n     エラー: %v\n     試行回数: %d (フェーズ: %s)\n", i, res.URL, res.Error, res.Attempts, res.Phase)
+	} else {
+		p.successCount++
+		if p.verbose {
+			fmt.Printf("✅ [%d] %s\n     抽出コンテンツの長さ: %d 文字\n     プレビュー: %s...\n",
+				i, res.URL, len(res.Content), res.Content[:min(len(res.Content), 50)])
+		} else {
+			fmt.Printf("✅ [%d] %s\n     抽出コンテンツの長さ: %d 文字\n", i, res.URL, len(res.Content))
+		}
+	}
+}
+
+// summary は、出力した結果の件数を出力します。
+func (p *resultPrinter) summary() {
+	p.header()
+	fmt.Println("-------------------------------")
+	log.Printf("完了: 成功 %d 件, 失敗 %d 件, robots.txt によるスキップ %d 件, 後処理による除外 %d 件\n", p.successCount, p.errorCount, p.disallowedCount, p.droppedCount)
+}
@@ -1,9 +1,19 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
+	"net/http"
 	"time"
 
+	"github.com/shouni/web-text-pipe-go/pkg/fetchconf"
+	"github.com/shouni/web-text-pipe-go/pkg/hostlimit"
+	"github.com/shouni/web-text-pipe-go/pkg/normalize"
+	"github.com/shouni/web-text-pipe-go/pkg/pipeline"
+	"github.com/shouni/web-text-pipe-go/pkg/robots"
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+	"github.com/shouni/web-text-pipe-go/pkg/tracing"
+
 	clibase "github.com/shouni/go-cli-base"
 	"github.com/spf13/cobra"
 )
@@ -13,15 +23,41 @@ import (
 const (
 	appName           = "web-text-pipe"
 	defaultTimeoutSec = 15 // 秒 (並列スクレイピングを考慮して初期値を15秒に設定)
-	defaultMaxRetries = 3  // デフォルトのリトライ回数
 )
 
 // --- グローバル変数とフラグ構造体 ---
 
 // AppFlags はこのアプリケーション固有の永続フラグを保持
 type AppFlags struct {
-	TimeoutSec int // --timeout HTTPリクエストのタイムアウト
-	MaxRetries int // --max-retries リトライ回数
+	TimeoutSec        int           // --timeout HTTPリクエストのタイムアウト
+	MaxRetries        int           // --max-retries リトライ回数
+	RetryInitialDelay time.Duration // --retry-initial-delay 並列抽出後の待機時間
+	RetryBaseDelay    time.Duration // --retry-base-delay 1回目のリトライ前の待機時間
+	RetryMaxDelay     time.Duration // --retry-max-delay リトライ前の待機時間の上限
+	RetryJitter       float64       // --retry-jitter 待機時間に加える揺らぎの割合
+	StateFile         string        // --state-file 抽出済みURLを記録する状態ストアのパス
+	HostRateLimit     time.Duration // --host-rate-limit 同一ホストへのリクエストの最小間隔
+	HostBurst         int           // --host-burst 同一ホストへのリクエストのバーストサイズ
+	HostMaxInFlight   int           // --host-max-in-flight 同一ホストへの同時リクエスト数の上限
+	HostLimits        []string      // --host-limit ドメインごとの制限の上書き
+	HostLimitsFile    string        // --host-limits-file ドメインごとの制限の上書きを記述したファイル
+	UserAgent         string        // --user-agent 記事・フィードの取得に使用する User-Agent
+	Headers           []string      // --header すべてのリクエストに付加するヘッダー
+	CookiesFile       string        // --cookies Netscape 形式の cookies.txt
+	HTTPConfigFile    string        // --http-config ドメインごとのヘッダーなどを記述した HTTP 設定ファイル
+	Proxy             string        // --proxy すべてのリクエストに使用するプロキシ
+	ProxyFile         string        // --proxy-file プロキシプールのファイル
+	ProxyMode         string        // --proxy-mode プロキシプールからの選択方式
+	Normalize         bool          // --normalize 抽出した本文を NFKC で正規化し、空白を圧縮する
+	StripEmoji        bool          // --strip-emoji 抽出した本文から絵文字を除去する
+	PipelineFile      string        // --pipeline 抽出後の記事に適用する後処理のパイプラインの設定ファイル
+	RobotsUserAgent   string        // --robots-user-agent robots.txt の User-agent と照合するトークン
+	IgnoreRobots      bool          // --ignore-robots robots.txt を無視する
+	OTelEndpoint      string        // --otel-endpoint トレースを送信する OTLP コレクターのエンドポイント
+	OTelProtocol      string        // --otel-protocol OTLP のプロトコル (grpc / http)
+	OTelInsecure      bool          // --otel-insecure TLS を使用せずにコレクターに接続する
+	OTelServiceName   string        // --otel-service-name service.name リソース属性
+	OTelSampleRatio   float64       // --otel-sample-ratio 記録するトレースの割合
 }
 
 var Flags AppFlags // アプリケーション固有フラグにアクセスするためのグローバル変数
@@ -40,9 +76,297 @@ func addAppPersistentFlags(rootCmd *cobra.Command) {
 	rootCmd.PersistentFlags().IntVar(
 		&Flags.MaxRetries,
 		"max-retries",
-		defaultMaxRetries,
-		"HTTPリクエストのリトライ最大回数",
+		runner.DefaultMaxAttempts,
+		"失敗したURLに対するワークフロー層のリトライ最大回数 (0 でリトライしない)",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&Flags.RetryInitialDelay,
+		"retry-initial-delay",
+		runner.DefaultInitialDelay,
+		"並列抽出の完了後、リトライ処理に進む前の待機時間",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&Flags.RetryBaseDelay,
+		"retry-base-delay",
+		runner.DefaultBaseDelay,
+		"1回目のリトライ前の待機時間 (以降は指数的に増加)",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&Flags.RetryMaxDelay,
+		"retry-max-delay",
+		runner.DefaultMaxDelay,
+		"リトライ前の待機時間の上限",
+	)
+	rootCmd.PersistentFlags().Float64Var(
+		&Flags.RetryJitter,
+		"retry-jitter",
+		runner.DefaultJitter,
+		"リトライ前の待機時間に加える揺らぎの割合 (0.2 なら ±20%)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.StateFile,
+		"state-file",
+		"",
+		"抽出済みURLを記録する状態ストアのパス。指定すると、抽出済みの記事をスキップします",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&Flags.HostRateLimit,
+		"host-rate-limit",
+		hostlimit.DefaultInterval,
+		"同一ホストへのリクエストの最小間隔 (ホストごとのトークンバケットの補充間隔)",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&Flags.HostBurst,
+		"host-burst",
+		hostlimit.DefaultBurst,
+		"同一ホストへのリクエストのバーストサイズ",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&Flags.HostMaxInFlight,
+		"host-max-in-flight",
+		hostlimit.DefaultMaxInFlight,
+		"同一ホストへの同時リクエスト数の上限",
+	)
+	rootCmd.PersistentFlags().StringArrayVar(
+		&Flags.HostLimits,
+		"host-limit",
+		nil,
+		"ドメインごとの制限の上書き (例: \"example.com interval=2s max-in-flight=1\")。複数回指定できます",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.HostLimitsFile,
+		"host-limits-file",
+		"",
+		"ドメインごとの制限の上書きを1行に1件記述したファイル (--host-limit と同じ形式)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.UserAgent,
+		"user-agent",
+		"",
+		"記事・フィードの取得に使用する User-Agent。省略時はブラウザ相当の User-Agent を使用します",
+	)
+	rootCmd.PersistentFlags().StringArrayVar(
+		&Flags.Headers,
+		"header",
+		nil,
+		"すべてのリクエストに付加するヘッダー (例: \"Accept-Language: ja\")。複数回指定できます",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.CookiesFile,
+		"cookies",
+		"",
+		"リクエストに付加する Cookie を記述した Netscape 形式の cookies.txt",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.HTTPConfigFile,
+		"http-config",
+		"",
+		"User-Agent・ヘッダー・Cookie ファイルとドメインごとの設定を記述した JSON ファイル",
 	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.Proxy,
+		"proxy",
+		"",
+		"すべてのリクエストに使用するプロキシ (例: http://proxy.example.com:8080, socks5://127.0.0.1:1080)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.ProxyFile,
+		"proxy-file",
+		"",
+		"1行に1件のプロキシURLを記述したプロキシプールのファイル",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.ProxyMode,
+		"proxy-mode",
+		"",
+		"プロキシプールからの選択方式 (round-robin: リクエストごとに順番に使用 / sticky: ホストごとに同じプロキシを使用)。省略時は --http-config の設定、または round-robin",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&Flags.Normalize,
+		"normalize",
+		false,
+		"抽出した本文を正規化します (NFKC による全角英数字・半角カナの統一、ゼロ幅文字の除去、空白・空行の圧縮)",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&Flags.StripEmoji,
+		"strip-emoji",
+		false,
+		"抽出した本文から絵文字を除去します (© や ™ などテキストとして表示される記号は残す)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.PipelineFile,
+		"pipeline",
+		"",
+		"抽出後の記事に適用する後処理 (フィルター・正規化・メタデータの付加・出力) のステージを記述した設定ファイル (JSON)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.RobotsUserAgent,
+		"robots-user-agent",
+		robots.DefaultUserAgent,
+		"robots.txt の User-agent と照合するトークン (--user-agent の省略時は robots.txt の取得時の User-Agent にも使用)",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&Flags.IgnoreRobots,
+		"ignore-robots",
+		false,
+		"robots.txt による除外と Crawl-delay の反映を行いません (許可を得たサイトのみで使用してください)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.OTelEndpoint,
+		"otel-endpoint",
+		"",
+		"OpenTelemetry のトレースを送信する OTLP コレクターのエンドポイント (例: localhost:4317)。省略時はトレースを無効にします",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.OTelProtocol,
+		"otel-protocol",
+		tracing.ProtocolGRPC,
+		"OTLP のプロトコル (grpc / http)",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&Flags.OTelInsecure,
+		"otel-insecure",
+		false,
+		"TLS を使用せずに OTLP コレクターに接続します (ローカルのコレクター向け)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&Flags.OTelServiceName,
+		"otel-service-name",
+		tracing.DefaultServiceName,
+		"トレースの service.name リソース属性",
+	)
+	rootCmd.PersistentFlags().Float64Var(
+		&Flags.OTelSampleRatio,
+		"otel-sample-ratio",
+		1.0,
+		"記録するトレースの割合 (0 から 1)",
+	)
+}
+
+// retryPolicyFromFlags は、永続フラグの値から runner.RetryPolicy を構築します。
+func retryPolicyFromFlags() runner.RetryPolicy {
+	return runner.RetryPolicy{
+		MaxAttempts:  Flags.MaxRetries,
+		InitialDelay: Flags.RetryInitialDelay,
+		BaseDelay:    Flags.RetryBaseDelay,
+		MaxDelay:     Flags.RetryMaxDelay,
+		Jitter:       Flags.RetryJitter,
+	}
+}
+
+// hostLimitsFromFlags は、永続フラグの値から hostlimit.Config を構築します。
+// --host-limits-file の上書き設定より、--host-limit の上書き設定を優先します。
+func hostLimitsFromFlags() (hostlimit.Config, error) {
+	config := hostlimit.Config{
+		Default: hostlimit.Limit{
+			Interval:    Flags.HostRateLimit,
+			Burst:       Flags.HostBurst,
+			MaxInFlight: Flags.HostMaxInFlight,
+		},
+		Overrides: make(map[string]hostlimit.Limit),
+	}
+
+	if Flags.HostLimitsFile != "" {
+		overrides, err := hostlimit.LoadOverrides(Flags.HostLimitsFile)
+		if err != nil {
+			return config, fmt.Errorf("エラー: %w", err)
+		}
+		config.Overrides = overrides
+	}
+	for _, rule := range Flags.HostLimits {
+		domain, limit, err := hostlimit.ParseRule(rule)
+		if err != nil {
+			return config, fmt.Errorf("エラー: --host-limit: %w", err)
+		}
+		config.Overrides[domain] = limit
+	}
+	return config, nil
+}
+
+// normalizerFromFlags は、永続フラグの値から本文の正規化を構築します。正規化が無効の場合は nil を返します。
+func normalizerFromFlags() *normalize.Normalizer {
+	return normalize.New(normalize.Options{
+		NFKC:               Flags.Normalize,
+		CollapseWhitespace: Flags.Normalize,
+		StripEmoji:         Flags.StripEmoji,
+	})
+}
+
+// pipelineFromFlags は、--pipeline の設定ファイルから後処理のパイプラインを構築します。
+// 指定されていない場合は nil を返します。呼び出し元で Close してください (nil でも安全です)。
+func pipelineFromFlags() (*pipeline.Pipeline, error) {
+	if Flags.PipelineFile == "" {
+		return nil, nil
+	}
+	config, err := pipeline.Load(Flags.PipelineFile)
+	if err != nil {
+		return nil, err
+	}
+	p, err := pipeline.Build(config)
+	if err != nil {
+		return nil, fmt.Errorf("パイプラインの構築エラー (%s): %w", Flags.PipelineFile, err)
+	}
+	return p, nil
+}
+
+// closePipeline は、後処理のパイプラインを閉じ、エラーをログに出力します (defer で使用します)。
+func closePipeline(p *pipeline.Pipeline) {
+	if err := p.Close(); err != nil {
+		log.Printf("⚠️ 後処理のパイプラインの終了エラー: %v\n", err)
+	}
+}
+
+// newHTTPClient は、永続フラグと --http-config の設定を適用した http.Client を構築します。
+// すべてのコマンドの取得で共有します。フラグの値は設定ファイルのグローバルな設定より優先します。
+func newHTTPClient(timeout time.Duration) (*http.Client, error) {
+	var config fetchconf.Config
+	if Flags.HTTPConfigFile != "" {
+		var err error
+		config, err = fetchconf.Load(Flags.HTTPConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("エラー: %w", err)
+		}
+	}
+
+	if Flags.UserAgent != "" {
+		config.UserAgent = Flags.UserAgent
+	}
+	if Flags.CookiesFile != "" {
+		config.CookiesFile = Flags.CookiesFile
+	}
+	if Flags.Proxy != "" || Flags.ProxyFile != "" {
+		// フラグでプロキシが指定された場合は、設定ファイルのプロキシを置き換える
+		config.Proxies = nil
+		if Flags.Proxy != "" {
+			config.Proxies = append(config.Proxies, Flags.Proxy)
+		}
+		if Flags.ProxyFile != "" {
+			proxies, err := fetchconf.LoadProxies(Flags.ProxyFile)
+			if err != nil {
+				return nil, fmt.Errorf("エラー: %w", err)
+			}
+			config.Proxies = append(config.Proxies, proxies...)
+		}
+	}
+	if Flags.ProxyMode != "" {
+		config.ProxyMode = Flags.ProxyMode
+	}
+	if len(Flags.Headers) > 0 && config.Headers == nil {
+		config.Headers = make(map[string]string, len(Flags.Headers))
+	}
+	for _, header := range Flags.Headers {
+		name, value, err := fetchconf.ParseHeader(header)
+		if err != nil {
+			return nil, fmt.Errorf("エラー: --header: %w", err)
+		}
+		config.Headers[name] = value
+	}
+
+	client, err := config.NewClient(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("エラー: %w", err)
+	}
+	return client, nil
 }
 
 // initAppPreRunE は、アプリケーション固有のPersistentPreRunEです。
@@ -54,7 +378,27 @@ func initAppPreRunE(cmd *cobra.Command, args []string) error {
 	// clibase.Flags の利用
 	if clibase.Flags.Verbose {
 		log.Printf("HTTPクライアントのタイムアウトを設定しました (Timeout: %s)。", timeout)
-		log.Printf("HTTPクライアントのリトライ回数を設定しました (MaxRetries: %d)。", Flags.MaxRetries)
+		log.Printf("失敗URLのリトライ回数を設定しました (MaxRetries: %d, BaseDelay: %s, MaxDelay: %s)。",
+			Flags.MaxRetries, Flags.RetryBaseDelay, Flags.RetryMaxDelay)
+	}
+
+	if Flags.MaxRetries < 0 {
+		return fmt.Errorf("エラー: --max-retries には 0 以上の値を指定してください (指定値: %d)", Flags.MaxRetries)
+	}
+	if Flags.RetryJitter < 0 || Flags.RetryJitter > 1 {
+		return fmt.Errorf("エラー: --retry-jitter には 0 から 1 の範囲の値を指定してください (指定値: %g)", Flags.RetryJitter)
+	}
+	if Flags.HostRateLimit < 0 || Flags.HostBurst < 0 || Flags.HostMaxInFlight < 0 {
+		return fmt.Errorf("エラー: --host-rate-limit, --host-burst, --host-max-in-flight には 0 以上の値を指定してください (0 の場合はデフォルト値を使用します)")
+	}
+	if Flags.ProxyMode != "" && Flags.ProxyMode != fetchconf.ProxyRoundRobin && Flags.ProxyMode != fetchconf.ProxySticky {
+		return fmt.Errorf("エラー: 無効なプロキシの選択方式です (--proxy-mode: %s)。%s または %s を指定してください", Flags.ProxyMode, fetchconf.ProxyRoundRobin, fetchconf.ProxySticky)
+	}
+	if Flags.OTelProtocol != tracing.ProtocolGRPC && Flags.OTelProtocol != tracing.ProtocolHTTP {
+		return fmt.Errorf("エラー: 無効な OTLP プロトコルです (--otel-protocol: %s)。%s または %s を指定してください", Flags.OTelProtocol, tracing.ProtocolGRPC, tracing.ProtocolHTTP)
+	}
+	if Flags.OTelSampleRatio < 0 || Flags.OTelSampleRatio > 1 {
+		return fmt.Errorf("エラー: --otel-sample-ratio には 0 から 1 の範囲の値を指定してください (指定値: %g)", Flags.OTelSampleRatio)
 	}
 
 	// WebTextPipeには必須の環境変数チェックはないため、ここでは特別なロジックを追加しません。
@@ -65,6 +409,10 @@ func initAppPreRunE(cmd *cobra.Command, args []string) error {
 func initCmdFlags() {
 	initScraperFlags()
 	initExactFlags()
+	initBatchFlags()
+	initStateFlags()
+	initWatchFlags()
+	initServeFlags()
 }
 
 // --- エントリポイント ---
@@ -82,5 +430,9 @@ func Execute() {
 		initAppPreRunE,        // カスタムPersistentPreRunEコールバック
 		scraperCmd,
 		exactCmd,
+		batchCmd,
+		stateCmd,
+		watchCmd,
+		serveCmd,
 	)
 }
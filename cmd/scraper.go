@@ -4,97 +4,205 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/shouni/web-text-pipe-go/pkg/builder"
+	"github.com/shouni/web-text-pipe-go/pkg/opml"
 	"github.com/shouni/web-text-pipe-go/pkg/runner"
 
-	"github.com/shouni/go-cli-base"
 	"github.com/shouni/go-web-exact/v2/pkg/scraper"
-	"github.com/shouni/go-web-exact/v2/pkg/types"
 	"github.com/spf13/cobra"
 )
 
-// --- ロジック: 結果の出力 (I/O) ---
-
-// printResults は、runnerから受け取った結果をCLIに出力します。
-func printResults(results []types.URLResult, verbose bool) {
-	fmt.Println("\n--- 並列スクレイピング結果 ---")
-	successCount := 0
-	errorCount := 0
-
-	for i, res := range results {
-		if res.Error != nil {
-			errorCount++
-			log.Printf("❌ [%d] %s\n     エラー: %v\n", i+1, res.URL, res.Error)
-		} else {
-			successCount++
-			if verbose {
-				fmt.Printf("✅ [%d] %s\n     抽出コンテンツの長さ: %d 文字\n     プレビュー: %s...\n",
-					i+1, res.URL, len(res.Content), res.Content[:min(len(res.Content), 50)])
-			} else {
-				fmt.Printf("✅ [%d] %s\n     抽出コンテンツの長さ: %d 文字\n", i+1, res.URL, len(res.Content))
-			}
+// --- ロジック: フラグ値の解析 ---
+
+// parseSince は、--since フラグの値を解析します。
+// 期間 (例: 24h) の場合は now からの相対時刻、日付・日時の場合はその時刻を返します。空文字列の場合はゼロ値を返します。
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
 		}
 	}
-
-	fmt.Println("-------------------------------")
-	log.Printf("完了: 成功 %d 件, 失敗 %d 件\n", successCount, errorCount)
+	return time.Time{}, fmt.Errorf("エラー: 無効な --since の値です (%s)。期間 (例: 24h) または日付 (例: 2025-01-02) を指定してください", value)
 }
 
 // --- サブコマンド定義 ---
 
 var scraperCmd = &cobra.Command{
 	Use:   "scraper",
-	Short: "RSSフィードからURLを抽出し、Webコンテンツを並列で取得・整形します",
-	Long: `--url フラグで指定されたRSS/Atomフィードを解析し、含まれる記事のURLを抽出し、
-指定された最大同時実行数で並列にコンテンツ抽出を実行します。`,
+	Short: "RSSフィードやサイトマップからURLを抽出し、Webコンテンツを並列で取得・整形します",
+	Long: `--url フラグ (複数指定可) または --opml で指定されたRSS/Atomフィード、および --sitemap で指定された
+サイトマップを並行して解析し、含まれる記事のURLを重複排除して抽出し、指定された最大同時実行数で並列にコンテンツ抽出を実行します。`,
 	Args: cobra.NoArgs,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 1. フラグ値の取得と設定の構築
-		feedURL, _ := cmd.Flags().GetString("url")
+		feedURLs, _ := cmd.Flags().GetStringArray("url")
+		opmlPath, _ := cmd.Flags().GetString("opml")
+		sitemapURLs, _ := cmd.Flags().GetStringArray("sitemap")
+		sinceValue, _ := cmd.Flags().GetString("since")
+		sinceLastRun, _ := cmd.Flags().GetBool("since-last-run")
+		force, _ := cmd.Flags().GetBool("force")
 		concurrency, _ := cmd.Flags().GetInt("concurrency")
 		clientTimeout := time.Duration(Flags.TimeoutSec) * time.Second
 
+		outputOpts, err := getOutputOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		// --opml や --sitemap が指定され、--url が明示されていない場合は、デフォルトのフィードURLを混ぜない
+		if (opmlPath != "" || len(sitemapURLs) > 0) && !cmd.Flags().Changed("url") {
+			feedURLs = nil
+		}
+		if opmlPath != "" {
+			opmlURLs, err := opml.LoadFeedURLs(opmlPath)
+			if err != nil {
+				return err
+			}
+			feedURLs = append(feedURLs, opmlURLs...)
+			log.Printf("OPMLファイルからフィードURLを読み込みました: %d 件 (%s)\n", len(opmlURLs), opmlPath)
+		}
+
+		since, err := parseSince(sinceValue, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if sinceLastRun && Flags.StateFile == "" {
+			return fmt.Errorf("エラー: --since-last-run には状態ストアのパス (--state-file) の指定が必要です")
+		}
+
 		// 2. Runnerを取得
-		runnerInstance, err := builder.BuildScraperRunner(clientTimeout, concurrency)
+		stopTracing, err := startTracing()
 		if err != nil {
 			return err
 		}
+		defer stopTracing()
+
+		store, err := openStateStore()
+		if err != nil {
+			return err
+		}
+		hostLimits, err := hostLimitsFromFlags()
+		if err != nil {
+			return err
+		}
+		httpClient, err := newHTTPClient(clientTimeout)
+		if err != nil {
+			return err
+		}
+		pipe, err := pipelineFromFlags()
+		if err != nil {
+			return err
+		}
+		defer closePipeline(pipe)
+		m, pushMetrics := newPushgatewayMetrics(cmd)
+		defer pushMetrics()
+		buildOpts := builder.Options{
+			ClientTimeout:   clientTimeout,
+			HTTPClient:      httpClient,
+			Concurrency:     concurrency,
+			RetryPolicy:     retryPolicyFromFlags(),
+			HostLimits:      hostLimits,
+			RobotsUserAgent: Flags.RobotsUserAgent,
+			IgnoreRobots:    Flags.IgnoreRobots,
+			Normalizer:      normalizerFromFlags(),
+			Pipeline:        pipe,
+			Metrics:         m,
+		}
+		if store != nil {
+			defer store.Close()
+			// 状態ストアにフィードの検証子を保存し、更新のないフィードの再取得を避ける
+			// (--force の場合、Runner は検証子を付与せずにフィードを取得する)
+			buildOpts.FeedValidators = store
+		}
+
+		runnerInstance, err := builder.BuildScraperRunner(buildOpts)
+		if err != nil {
+			return err
+		}
+		if store != nil {
+			runnerInstance.StateStore = store
+		}
 
 		// 3. 実行コンテキストと設定の準備
-		ctx := context.Background()
+		// Ctrl-C (SIGINT) / SIGTERM を受け取った場合は、待機やリトライを中断して取得済みの結果を出力する
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 		config := runner.RunnerConfig{
-			FeedURL:                  feedURL,
+			FeedURLs:                 feedURLs,
+			SitemapURLs:              sitemapURLs,
+			Since:                    since,
+			SinceLastRun:             sinceLastRun,
+			Force:                    force,
 			ClientTimeout:            clientTimeout,
 			OverallTimeoutMultiplier: 3,
 		}
 
-		// 4. ScrapeAndRun の呼び出し
-		// 修正: 戻り値の型を *runner.RunnerResult に変更
-		runnerResult, err := runnerInstance.ScrapeAndRun(ctx, config)
+		// 4. ScrapeAndStream の呼び出し
+		// 記事の結果は、すべての記事の完了を待たずに確定した順に出力する
+		writer := newResultWriter(outputOpts)
+		defer writer.Close()
+		startedAt := time.Now()
+		runnerResult, err := runnerInstance.ScrapeAndStream(ctx, config, writer.Write)
 		if err != nil {
-			return err
+			return finishRun(cmd, outputOpts, nil, err, startedAt)
+		}
+
+		for _, summary := range runnerResult.Feeds {
+			if summary.Error != nil {
+				log.Printf("⚠️ フィードの取得に失敗しました (URL: %s): %v\n", summary.URL, summary.Error)
+			}
+		}
+
+		if len(runnerResult.Skipped) > 0 {
+			log.Printf("抽出済みの記事を %d 件スキップしました (--force で再抽出できます)\n", len(runnerResult.Skipped))
+		}
+
+		if runnerResult.NotModified {
+			log.Println("すべてのフィードが前回の取得から更新されていないため、スクレイピングをスキップしました。")
+			// 更新がない場合も、出力先のファイル・マニフェストとサマリーを出力する
+			if err := writer.Finish(runnerResult); err != nil {
+				return err
+			}
+			return finishRun(cmd, outputOpts, runnerResult, nil, startedAt)
 		}
 
 		// 抽出結果の確認
-		if len(runnerResult.Results) == 0 {
+		if len(runnerResult.Results) == 0 && len(runnerResult.Skipped) == 0 {
 			// runner.ScrapeAndRun が既にエラーチェックをしているはずだが、念のため
 			log.Printf("エラー: スクレピング結果が一つもありませんでした。フィードタイトル: %s\n", runnerResult.FeedTitle)
 		}
 
-		// 5. 結果の出力
-		// 修正: RunnerResult から Results スライスを取り出して渡す
-		printResults(runnerResult.Results, clibase.Flags.Verbose)
-
-		return nil
+		// 5. 結果の出力の完了
+		if err := writer.Finish(runnerResult); err != nil {
+			return err
+		}
+		return finishRun(cmd, outputOpts, runnerResult, nil, startedAt)
 	},
 }
 
 // --- フラグ初期化 ---
 
 func initScraperFlags() {
-	scraperCmd.Flags().StringP("url", "u", "https://news.yahoo.co.jp/rss/categories/it.xml", "解析対象のフィードURL (RSS/Atom)")
+	scraperCmd.Flags().StringArrayP("url", "u", []string{"https://news.yahoo.co.jp/rss/categories/it.xml"}, "解析対象のフィードURL (RSS/Atom)。複数回指定可能")
+	scraperCmd.Flags().String("opml", "", "解析対象のフィードURLを列挙したOPMLファイルのパス")
+	scraperCmd.Flags().StringArray("sitemap", nil, "解析対象のサイトマップ (sitemap.xml / サイトマップインデックス / Google News サイトマップ) のURL。複数回指定可能")
+	scraperCmd.Flags().String("since", "", "公開・更新日時がこれより前の記事を除外します (例: 24h, 2025-01-02, 2025-01-02T15:04:05+09:00)")
+	scraperCmd.Flags().Bool("since-last-run", false, "状態ストアに記録された前回の実行日時より前の記事を除外します (--state-file が必要)")
+	scraperCmd.Flags().Bool("force", false, "状態ストアに抽出済みとして記録されている記事も再抽出します")
 	scraperCmd.Flags().IntP("concurrency", "c", scraper.DefaultMaxConcurrency, "最大並列実行数 (デフォルト: 10)")
+	addOutputFlags(scraperCmd)
+	addPushgatewayFlags(scraperCmd)
 }
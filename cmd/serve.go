@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/builder"
+	"github.com/shouni/web-text-pipe-go/pkg/jobs"
+	"github.com/shouni/web-text-pipe-go/pkg/metrics"
+	"github.com/shouni/web-text-pipe-go/pkg/server"
+
+	"github.com/shouni/go-web-exact/v2/pkg/scraper"
+	"github.com/spf13/cobra"
+)
+
+// --- サブコマンド定義 ---
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "exact / scraper の機能を JSON API として提供する HTTP サーバーを起動します",
+	Long: `exact と scraper の機能を HTTP API として提供し、他のサービスから呼び出せるようにします。
+  POST /extract  {"url": "...", "timeout_sec": 15}          単一URLの本文抽出
+  POST /scrape   {"feed_urls": ["..."], "timeout_sec": 15}  フィードの並列スクレイピング
+  POST /jobs     {"feed_urls": ["..."]}                     スクレイピングの非同期ジョブを投入
+  GET  /jobs/{id}                                          ジョブの進捗と結果の取得
+  GET  /metrics                                            Prometheus メトリクス
+  GET  /healthz, GET /readyz                               ヘルスチェック
+timeout_sec は記事・フィードの取得1回ごとのタイムアウト (秒) で、省略時は --timeout の値を使用します。
+同時処理数が --max-concurrent に達している場合は 503 を返します。
+ジョブは --jobs-file に保存され、停止時に実行中だったジョブは次回の起動時に再実行されます。
+終了したジョブは --job-ttl の期間を過ぎると削除されます。`,
+	Args: cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 1. フラグ値の取得
+		addr, _ := cmd.Flags().GetString("addr")
+		maxConcurrent, _ := cmd.Flags().GetInt("max-concurrent")
+		maxTimeout, _ := cmd.Flags().GetDuration("max-timeout")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		jobsFile, _ := cmd.Flags().GetString("jobs-file")
+		jobWorkers, _ := cmd.Flags().GetInt("job-workers")
+		maxQueuedJobs, _ := cmd.Flags().GetInt("max-queued-jobs")
+		jobTTL, _ := cmd.Flags().GetDuration("job-ttl")
+		clientTimeout := time.Duration(Flags.TimeoutSec) * time.Second
+
+		if maxConcurrent <= 0 {
+			return fmt.Errorf("エラー: --max-concurrent には 1 以上の値を指定してください (指定値: %d)", maxConcurrent)
+		}
+
+		// 2. 依存関係の構築
+		stopTracing, err := startTracing()
+		if err != nil {
+			return err
+		}
+		defer stopTracing()
+
+		hostLimits, err := hostLimitsFromFlags()
+		if err != nil {
+			return err
+		}
+		httpClient, err := newHTTPClient(maxTimeout)
+		if err != nil {
+			return err
+		}
+		pipe, err := pipelineFromFlags()
+		if err != nil {
+			return err
+		}
+		defer closePipeline(pipe)
+
+		// HTTPクライアントのタイムアウトは上限値で生成し、リクエストごとのタイムアウトはコンテキストで制御する
+		m := metrics.New(true)
+		runnerInstance, err := builder.BuildScraperRunner(builder.Options{
+			ClientTimeout:   maxTimeout,
+			HTTPClient:      httpClient,
+			Concurrency:     concurrency,
+			RetryPolicy:     retryPolicyFromFlags(),
+			HostLimits:      hostLimits,
+			RobotsUserAgent: Flags.RobotsUserAgent,
+			IgnoreRobots:    Flags.IgnoreRobots,
+			Normalizer:      normalizerFromFlags(),
+			Pipeline:        pipe,
+			Metrics:         m,
+		})
+		if err != nil {
+			return err
+		}
+		// 単一URL抽出にも、スクレイピングと同じ HTTP 設定を適用する
+		fetcher := builder.BuildFetcher(builder.Options{ClientTimeout: maxTimeout, HTTPClient: httpClient, Metrics: m})
+		normalizer := normalizerFromFlags()
+		extractFunc := func(ctx context.Context, url string) (string, bool, error) {
+			return runExactExtraction(ctx, fetcher, normalizer, pipe, url)
+		}
+
+		srv := server.New(runnerInstance, extractFunc, server.Config{
+			Addr:           addr,
+			MaxConcurrent:  maxConcurrent,
+			DefaultTimeout: clientTimeout,
+			MaxTimeout:     maxTimeout,
+		})
+		srv.MetricsHandler = m.Handler()
+
+		// 3. サーバーとジョブキューの起動 (SIGINT / SIGTERM で処理中のリクエストを待ってから停止)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		queueDone := make(chan error, 1)
+		if jobsFile == "" {
+			queueDone <- nil
+		} else {
+			jobStore, err := jobs.Open(jobsFile)
+			if err != nil {
+				return err
+			}
+			defer jobStore.Close()
+
+			srv.Jobs = jobs.NewQueue(jobStore, runnerInstance, jobWorkers, maxQueuedJobs)
+			srv.Jobs.TTL = jobTTL
+			go func() {
+				err := srv.Jobs.Run(ctx)
+				if err != nil {
+					// ジョブキューを開始できない場合はサーバーも停止させる
+					stop()
+				}
+				queueDone <- err
+			}()
+		}
+
+		serveErr := srv.ListenAndServe(ctx)
+		// サーバーが異常終了した場合もジョブキューを停止させる
+		stop()
+		if err := <-queueDone; err != nil {
+			return err
+		}
+		if serveErr != nil {
+			return serveErr
+		}
+		log.Println("HTTPサーバーを停止しました。")
+		return nil
+	},
+}
+
+// --- フラグ初期化 ---
+
+func initServeFlags() {
+	serveCmd.Flags().String("addr", server.DefaultAddr, "HTTPサーバーの待ち受けアドレス")
+	serveCmd.Flags().Int("max-concurrent", server.DefaultMaxConcurrent, "同時に処理する /extract・/scrape リクエストの最大数")
+	serveCmd.Flags().Duration("max-timeout", server.DefaultMaxTimeout, "リクエストで指定できるHTTPクライアントのタイムアウトの上限")
+	serveCmd.Flags().String("jobs-file", "jobs.db", "非同期ジョブを保存するファイルのパス。空文字列の場合は /jobs を提供しない")
+	serveCmd.Flags().Int("job-workers", jobs.DefaultWorkers, "同時に実行するジョブの最大数")
+	serveCmd.Flags().Int("max-queued-jobs", jobs.DefaultMaxQueued, "実行待ちにできるジョブの最大数")
+	serveCmd.Flags().Duration("job-ttl", jobs.DefaultTTL, "終了したジョブ (結果を含む) を保持する期間。経過したジョブは起動時と定期的に削除する (0 なら削除しない)")
+	serveCmd.Flags().IntP("concurrency", "c", scraper.DefaultMaxConcurrency, "/scrape の最大並列実行数 (デフォルト: 10)")
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/state"
+
+	"github.com/spf13/cobra"
+)
+
+// --- ロジック: 状態ストア ---
+
+// openStateStore は、--state-file で指定された状態ストアを開きます。
+// パスが指定されていない場合は nil を返します。
+func openStateStore() (*state.Store, error) {
+	if Flags.StateFile == "" {
+		return nil, nil
+	}
+	store, err := state.Open(Flags.StateFile)
+	if err != nil {
+		return nil, fmt.Errorf("エラー: %w", err)
+	}
+	return store, nil
+}
+
+// parseAge は、期間を表す文字列を解析します。time.ParseDuration の形式に加え、日数 (例: 30d) を受け付けます。
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("無効な日数です (%s)", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("無効な期間です (%s)", value)
+	}
+	return d, nil
+}
+
+// --- サブコマンド定義 ---
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "インクリメンタルスクレイピング用の状態ストアを管理します",
+	Long:  `--state-file で指定された状態ストア (抽出済みURLと最終実行日時の記録) を管理します。`,
+	Args:  cobra.NoArgs,
+}
+
+var statePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "古い抽出済みURLの記録を状態ストアから削除します",
+	Long:  `抽出日時が --older-than で指定された期間より前の記録を削除します。削除された記事は、次回の scraper 実行時に再び抽出対象となります。`,
+	Args:  cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		olderThanValue, _ := cmd.Flags().GetString("older-than")
+
+		olderThan, err := parseAge(olderThanValue)
+		if err != nil {
+			return fmt.Errorf("エラー: --older-than の値が不正です: %w", err)
+		}
+		if Flags.StateFile == "" {
+			return fmt.Errorf("エラー: 状態ストアのパス (--state-file) は必須です")
+		}
+
+		store, err := openStateStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		cutoff := time.Now().Add(-olderThan)
+		pruned, err := store.Prune(cutoff)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("状態ストアから %d 件の記録を削除しました (基準日時: %s)\n", pruned, cutoff.Format(time.RFC3339))
+		return nil
+	},
+}
+
+// --- フラグ初期化 ---
+
+func initStateFlags() {
+	statePruneCmd.Flags().String("older-than", "30d", "この期間より前に抽出された記録を削除します (例: 720h, 30d)")
+	stateCmd.AddCommand(statePruneCmd)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/tracing"
+)
+
+const tracingShutdownTimeout = 10 * time.Second // 終了時に未送信のスパンを送信する最大時間
+
+// startTracing は、永続フラグの設定に従ってトレースのエクスポートを開始し、終了時に呼び出す関数を返します。
+// --otel-endpoint が指定されていない場合は何もしません。
+func startTracing() (func(), error) {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:    Flags.OTelEndpoint,
+		Protocol:    Flags.OTelProtocol,
+		Insecure:    Flags.OTelInsecure,
+		ServiceName: Flags.OTelServiceName,
+		SampleRatio: Flags.OTelSampleRatio,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Printf("⚠️ トレースの送信に失敗しました (エンドポイント: %s): %v\n", Flags.OTelEndpoint, err)
+		}
+	}, nil
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/builder"
+	"github.com/shouni/web-text-pipe-go/pkg/feedcache"
+	"github.com/shouni/web-text-pipe-go/pkg/metrics"
+	"github.com/shouni/web-text-pipe-go/pkg/opml"
+	"github.com/shouni/web-text-pipe-go/pkg/output"
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+	"github.com/shouni/web-text-pipe-go/pkg/state"
+	"github.com/shouni/web-text-pipe-go/pkg/watch"
+
+	"github.com/shouni/go-web-exact/v2/pkg/scraper"
+	"github.com/spf13/cobra"
+)
+
+// --- ロジック: 監視対象の解析と結果の出力 ---
+
+// parseWatchTarget は、"URL" または "URL@間隔" (例: https://example.com/feed.xml@10m) 形式の値を解析します。
+// '@' 以降が期間として解釈できない場合は、値全体をURLとして扱います。
+func parseWatchTarget(value string) watch.Target {
+	if idx := strings.LastIndex(value, "@"); idx != -1 {
+		if interval, err := time.ParseDuration(value[idx+1:]); err == nil && interval > 0 {
+			return watch.Target{FeedURL: value[:idx], Interval: interval}
+		}
+	}
+	return watch.Target{FeedURL: value}
+}
+
+// watchOutput は、ポーリングで確定した記事の結果を、フィードごとの resultWriter で確定した順に出力します。
+// jsonl 形式の場合は、すべてのフィードの記事を同じ出力先に追記します。
+// watch.Watcher がハンドラの呼び出しを直列化するため、排他制御は行いません。
+type watchOutput struct {
+	format  string
+	out     io.Writer
+	writers map[string]*resultWriter // フィードURLごとの実行中のポーリングの出力
+}
+
+func newWatchOutput(format string, out io.Writer) *watchOutput {
+	return &watchOutput{format: format, out: out, writers: make(map[string]*resultWriter)}
+}
+
+// item は、記事の結果を出力します (watch.ItemHandler)。
+func (o *watchOutput) item(target watch.Target, runnerResult *runner.RunnerResult, res runner.ScrapeResult) {
+	w, ok := o.writers[target.FeedURL]
+	if !ok {
+		w = newResultWriter(outputOptions{Format: o.format})
+		w.out = o.out
+		o.writers[target.FeedURL] = w
+	}
+	w.Write(runnerResult, res)
+}
+
+// done は、1回のポーリングの出力を完了します (watch.ResultHandler)。
+func (o *watchOutput) done(target watch.Target, result *runner.RunnerResult, err error) {
+	w, ok := o.writers[target.FeedURL]
+	delete(o.writers, target.FeedURL)
+
+	if err != nil {
+		if ok {
+			w.Close()
+		}
+		log.Printf("❌ フィードの処理に失敗しました (URL: %s): %v\n", target.FeedURL, err)
+		return
+	}
+	if !ok {
+		log.Printf("新しい記事はありません (URL: %s, スキップ: %d 件)\n", target.FeedURL, len(result.Skipped))
+		return
+	}
+	if err := w.Finish(result); err != nil {
+		log.Printf("❌ 結果の出力エラー (URL: %s): %v\n", target.FeedURL, err)
+	}
+}
+
+// --- サブコマンド定義 ---
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "フィードを定期的にポーリングし、新しい記事のみを継続的に抽出します",
+	Long: `--url (複数指定可) または --opml で指定されたフィードを、フィードごとの間隔で定期的にポーリングします。
+単一の Runner を再利用し、抽出済みの記事と更新のないフィード (HTTP 304) はスキップします。
+--url に "URL@10m" のように間隔を付けると、そのフィードのみポーリング間隔を変更できます。
+SIGINT / SIGTERM を受信すると新たなポーリングを停止し、実行中の結果を出力してから終了します。`,
+	Args: cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 1. フラグ値の取得と設定の構築
+		urlValues, _ := cmd.Flags().GetStringArray("url")
+		opmlPath, _ := cmd.Flags().GetString("opml")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		jitter, _ := cmd.Flags().GetFloat64("jitter")
+		honorTTL, _ := cmd.Flags().GetBool("honor-feed-ttl")
+		shutdownTimeout, _ := cmd.Flags().GetDuration("shutdown-timeout")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		format, _ := cmd.Flags().GetString("format")
+		outputFile, _ := cmd.Flags().GetString("output")
+		metricsAddr, _ := cmd.Flags().GetString("metrics-addr")
+		clientTimeout := time.Duration(Flags.TimeoutSec) * time.Second
+
+		if format != output.FormatText && format != output.FormatJSONL {
+			return fmt.Errorf("エラー: 無効な出力形式です (--format: %s)。%s または %s を指定してください", format, output.FormatText, output.FormatJSONL)
+		}
+		if jitter < 0 || jitter > 1 {
+			return fmt.Errorf("エラー: --jitter には 0 から 1 の範囲の値を指定してください (指定値: %g)", jitter)
+		}
+
+		var targets []watch.Target
+		for _, value := range urlValues {
+			targets = append(targets, parseWatchTarget(value))
+		}
+		if opmlPath != "" {
+			opmlURLs, err := opml.LoadFeedURLs(opmlPath)
+			if err != nil {
+				return err
+			}
+			for _, u := range opmlURLs {
+				targets = append(targets, watch.Target{FeedURL: u})
+			}
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("エラー: 監視対象のフィードURL (--url または --opml) は必須です")
+		}
+
+		stopTracing, err := startTracing()
+		if err != nil {
+			return err
+		}
+		defer stopTracing()
+
+		// 2. 状態ストアの準備
+		// --state-file がない場合でも、プロセス内で抽出済みURLとフィードの検証子を保持して重複抽出を避ける
+		var seenStore runner.StateStore = state.NewMemoryStore()
+		var validators feedcache.ValidatorStore = feedcache.NewMemoryStore()
+		store, err := openStateStore()
+		if err != nil {
+			return err
+		}
+		if store != nil {
+			defer store.Close()
+			seenStore = store
+			validators = store
+		}
+
+		// 3. Runnerを取得 (全フィードで共有する)
+		hostLimits, err := hostLimitsFromFlags()
+		if err != nil {
+			return err
+		}
+		httpClient, err := newHTTPClient(clientTimeout)
+		if err != nil {
+			return err
+		}
+		pipe, err := pipelineFromFlags()
+		if err != nil {
+			return err
+		}
+		defer closePipeline(pipe)
+		var m *metrics.Metrics
+		if metricsAddr != "" {
+			m = metrics.New(true)
+		}
+		runnerInstance, err := builder.BuildScraperRunner(builder.Options{
+			ClientTimeout:   clientTimeout,
+			HTTPClient:      httpClient,
+			Concurrency:     concurrency,
+			RetryPolicy:     retryPolicyFromFlags(),
+			HostLimits:      hostLimits,
+			RobotsUserAgent: Flags.RobotsUserAgent,
+			IgnoreRobots:    Flags.IgnoreRobots,
+			Normalizer:      normalizerFromFlags(),
+			Pipeline:        pipe,
+			FeedValidators:  validators,
+			Metrics:         m,
+		})
+		if err != nil {
+			return err
+		}
+		runnerInstance.StateStore = seenStore
+
+		// 4. 出力先の準備 (jsonl 形式のファイル出力は追記)
+		var out io.Writer = os.Stdout
+		if format == output.FormatJSONL && outputFile != "" {
+			f, err := os.OpenFile(outputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+			if err != nil {
+				return fmt.Errorf("出力ファイルのオープンエラー (%s): %w", outputFile, err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		// 5. 監視の開始
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if m != nil {
+			go serveMetrics(ctx, metricsAddr, m)
+		}
+
+		results := newWatchOutput(format, out)
+		watcher := watch.NewWatcher(runnerInstance, watch.Config{
+			Targets:         targets,
+			DefaultInterval: interval,
+			Jitter:          jitter,
+			HonorFeedHints:  honorTTL,
+			ShutdownTimeout: shutdownTimeout,
+			RunnerConfig: runner.RunnerConfig{
+				ClientTimeout:            clientTimeout,
+				OverallTimeoutMultiplier: 3,
+			},
+		}, results.done)
+		watcher.OnItem = results.item
+
+		log.Printf("フィードの監視を開始します (フィード: %d 件, 間隔: %s)\n", len(targets), interval)
+		watcher.Run(ctx)
+
+		return nil
+	},
+}
+
+// --- フラグ初期化 ---
+
+func initWatchFlags() {
+	watchCmd.Flags().StringArrayP("url", "u", nil, "監視対象のフィードURL。\"URL@10m\" の形式でフィードごとの間隔を指定可能。複数回指定可能")
+	watchCmd.Flags().String("opml", "", "監視対象のフィードURLを列挙したOPMLファイルのパス")
+	watchCmd.Flags().Duration("interval", watch.DefaultInterval, "デフォルトのポーリング間隔")
+	watchCmd.Flags().Float64("jitter", watch.DefaultJitter, "ポーリング間隔に加える揺らぎの割合 (0.1 なら ±10%)")
+	watchCmd.Flags().Bool("honor-feed-ttl", true, "フィードが宣言する更新間隔 (<ttl> / sy:updatePeriod) がポーリング間隔より長い場合はそれに従う")
+	watchCmd.Flags().Duration("shutdown-timeout", watch.DefaultShutdownTimeout, "停止シグナル受信後、実行中のスクレイピングの完了を待つ最大時間")
+	watchCmd.Flags().IntP("concurrency", "c", scraper.DefaultMaxConcurrency, "最大並列実行数 (デフォルト: 10)")
+	watchCmd.Flags().StringP("format", "f", output.FormatText, "出力形式 (text: 人間向けのサマリー, jsonl: 1記事1行のJSON Lines)")
+	watchCmd.Flags().StringP("output", "o", "", "jsonl 形式の出力先ファイル名 (追記)。省略時は標準出力に出力。")
+	watchCmd.Flags().String("metrics-addr", "", "Prometheus メトリクスを /metrics で公開するアドレス (例: :9090)。省略時は公開しない")
+}
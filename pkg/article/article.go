@@ -0,0 +1,67 @@
+package article
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// Item は、フィード (RSS/Atom) のアイテムから取得した記事のメタデータです。
+type Item struct {
+	Title      string    `json:"title,omitempty"`
+	Published  time.Time `json:"published,omitzero"`
+	Updated    time.Time `json:"updated,omitzero"`
+	Authors    []string  `json:"authors,omitempty"`
+	Categories []string  `json:"categories,omitempty"`
+	GUID       string    `json:"guid,omitempty"`
+}
+
+// FromFeedItem は、gofeed のアイテムから Item を生成します。
+// 著者は名前を優先し、名前がない場合はメールアドレスを使用します。
+func FromFeedItem(item *gofeed.Item) Item {
+	meta := Item{
+		Title:      strings.TrimSpace(item.Title),
+		Categories: compact(item.Categories),
+		GUID:       strings.TrimSpace(item.GUID),
+	}
+	if item.PublishedParsed != nil {
+		meta.Published = *item.PublishedParsed
+	}
+	if item.UpdatedParsed != nil {
+		meta.Updated = *item.UpdatedParsed
+	}
+
+	authors := item.Authors
+	if len(authors) == 0 && item.Author != nil {
+		authors = []*gofeed.Person{item.Author}
+	}
+	names := make([]string, 0, len(authors))
+	for _, person := range authors {
+		if person == nil {
+			continue
+		}
+		if person.Name != "" {
+			names = append(names, person.Name)
+		} else {
+			names = append(names, person.Email)
+		}
+	}
+	meta.Authors = compact(names)
+	return meta
+}
+
+// compact は、順序を保ったまま前後の空白を取り除き、空文字列と重複を取り除きます。
+func compact(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var out []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package article
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/shouni/go-web-exact/v2/pkg/extract"
+)
+
+// Recorder は、取得したページごとのメタデータを記録します。
+// WithRecorder でコンテキストに設定すると、NewFetcher の Fetcher が取得のたびに記録します。
+type Recorder struct {
+	mu    sync.Mutex
+	pages map[string]*Page
+}
+
+// NewRecorder は、空の Recorder を初期化します。
+func NewRecorder() *Recorder {
+	return &Recorder{pages: make(map[string]*Page)}
+}
+
+// Page は、URLのページから取得したメタデータを返します。取得していない場合やメタデータがない場合は nil を返します。
+func (r *Recorder) Page(url string) *Page {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pages[url]
+}
+
+func (r *Recorder) set(url string, page *Page) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pages[url] = page
+}
+
+// recorderKey は、Recorder をコンテキストに格納するためのキーです。
+type recorderKey struct{}
+
+// WithRecorder は、ページのメタデータの記録先を設定したコンテキストを返します。
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// pageFetcher は、取得したHTMLからページのメタデータを取得し、コンテキストの Recorder に記録する extract.Fetcher です。
+type pageFetcher struct {
+	fetcher extract.Fetcher
+}
+
+// NewFetcher は、取得したHTMLからページのメタデータ (canonical・lang・OpenGraph・JSON-LD) を記録する extract.Fetcher を返します。
+// HTML は UTF-8 として解析するため、charset.NewFetcher でラップした Fetcher を渡してください。
+// コンテキストに Recorder が設定されていない場合は、HTMLを解析せずにそのまま返します。
+func NewFetcher(fetcher extract.Fetcher) extract.Fetcher {
+	return &pageFetcher{fetcher: fetcher}
+}
+
+func (f *pageFetcher) FetchBytes(ctx context.Context, url string) ([]byte, error) {
+	body, err := f.fetcher.FetchBytes(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok {
+		return body, nil
+	}
+	// メタデータを取得できなくても本文の抽出は続行する
+	page, err := ParsePage(body, url)
+	if err != nil {
+		slog.Warn("ページのメタデータの取得に失敗しました", slog.String("url", url), slog.Any("error", err))
+	}
+	r.set(url, page)
+	return body, nil
+}
@@ -0,0 +1,356 @@
+package article
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Page は、記事ページのHTMLから取得したメタデータです。
+type Page struct {
+	Canonical   string       `json:"canonical,omitempty"`    // <link rel="canonical"> のURL (絶対URLに解決済み)
+	Lang        string       `json:"lang,omitempty"`         // <html lang> の言語タグ
+	OpenGraph   *OpenGraph   `json:"open_graph,omitempty"`   // OpenGraph (og:* / article:*) のメタデータ
+	NewsArticle *NewsArticle `json:"news_article,omitempty"` // JSON-LD の記事 (NewsArticle など) のメタデータ
+}
+
+// OpenGraph は、<meta property="og:*"> と <meta property="article:*"> から取得したメタデータです。
+type OpenGraph struct {
+	Title         string    `json:"title,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	Type          string    `json:"type,omitempty"`
+	URL           string    `json:"url,omitempty"`
+	Image         string    `json:"image,omitempty"`
+	SiteName      string    `json:"site_name,omitempty"`
+	Locale        string    `json:"locale,omitempty"`
+	PublishedTime time.Time `json:"published_time,omitzero"`
+	ModifiedTime  time.Time `json:"modified_time,omitzero"`
+	Authors       []string  `json:"authors,omitempty"`
+	Section       string    `json:"section,omitempty"`
+	Tags          []string  `json:"tags,omitempty"`
+}
+
+// NewsArticle は、JSON-LD (schema.org) の NewsArticle・Article・BlogPosting などから取得したメタデータです。
+type NewsArticle struct {
+	Type          string    `json:"type"` // @type (例: NewsArticle)
+	Headline      string    `json:"headline,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	URL           string    `json:"url,omitempty"`
+	Authors       []string  `json:"authors,omitempty"`
+	Publisher     string    `json:"publisher,omitempty"`
+	DatePublished time.Time `json:"date_published,omitzero"`
+	DateModified  time.Time `json:"date_modified,omitzero"`
+	Section       string    `json:"section,omitempty"`
+	Keywords      []string  `json:"keywords,omitempty"`
+	Lang          string    `json:"lang,omitempty"` // inLanguage
+}
+
+// timeLayouts は、OpenGraph と JSON-LD の日時として受け付ける形式です (ISO 8601 の主な表記)。
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParsePage は、HTMLから記事ページのメタデータを取得します。
+// pageURL は、相対URLで記述された canonical を解決するために使用します。
+// メタデータが1つも見つからない場合は nil を返します。
+// 解析できない JSON-LD は、ページに誤りがあることが多いため、エラーとせずに無視します。
+func ParsePage(body []byte, pageURL string) (*Page, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("HTMLの解析エラー (URL: %s): %w", pageURL, err)
+	}
+
+	p := &pageParser{og: &OpenGraph{}}
+	p.walk(doc)
+
+	page := &Page{
+		Canonical:   resolveURL(pageURL, p.canonical),
+		Lang:        p.lang,
+		NewsArticle: findNewsArticle(p.jsonLD),
+	}
+	if !p.og.isZero() {
+		page.OpenGraph = p.og
+	}
+	if page.Canonical == "" && page.Lang == "" && page.OpenGraph == nil && page.NewsArticle == nil {
+		return nil, nil
+	}
+	return page, nil
+}
+
+// pageParser は、HTMLのノードを辿ってメタデータを収集します。
+type pageParser struct {
+	lang      string
+	canonical string
+	og        *OpenGraph
+	jsonLD    []any
+}
+
+func (p *pageParser) walk(n *html.Node) {
+	if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.Html:
+			if p.lang == "" {
+				p.lang = strings.TrimSpace(attr(n, "lang"))
+			}
+		case atom.Link:
+			if p.canonical == "" && hasToken(attr(n, "rel"), "canonical") {
+				p.canonical = strings.TrimSpace(attr(n, "href"))
+			}
+		case atom.Meta:
+			p.meta(n)
+		case atom.Script:
+			if strings.EqualFold(strings.TrimSpace(attr(n, "type")), "application/ld+json") {
+				p.script(n)
+			}
+			return
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.walk(c)
+	}
+}
+
+// isZero は、OpenGraph のメタデータが1つも見つからなかったかどうかを返します。
+func (og *OpenGraph) isZero() bool {
+	return og.Title == "" && og.Description == "" && og.Type == "" && og.URL == "" && og.Image == "" &&
+		og.SiteName == "" && og.Locale == "" && og.PublishedTime.IsZero() && og.ModifiedTime.IsZero() &&
+		len(og.Authors) == 0 && og.Section == "" && len(og.Tags) == 0
+}
+
+// meta は、OpenGraph の <meta> を OpenGraph に反映します。単一の値は最初に出現した値を使用します。
+// property 属性の代わりに name 属性を使用するサイトもあるため、両方を参照します。
+func (p *pageParser) meta(n *html.Node) {
+	key := attr(n, "property")
+	if key == "" {
+		key = attr(n, "name")
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	content := strings.TrimSpace(attr(n, "content"))
+	if content == "" {
+		return
+	}
+
+	og := p.og
+	switch key {
+	case "og:title":
+		setOnce(&og.Title, content)
+	case "og:description":
+		setOnce(&og.Description, content)
+	case "og:type":
+		setOnce(&og.Type, content)
+	case "og:url":
+		setOnce(&og.URL, content)
+	case "og:image", "og:image:url":
+		setOnce(&og.Image, content)
+	case "og:site_name":
+		setOnce(&og.SiteName, content)
+	case "og:locale":
+		setOnce(&og.Locale, content)
+	case "article:published_time":
+		if og.PublishedTime.IsZero() {
+			og.PublishedTime = parseTime(content)
+		}
+	case "article:modified_time":
+		if og.ModifiedTime.IsZero() {
+			og.ModifiedTime = parseTime(content)
+		}
+	case "article:author":
+		og.Authors = appendUnique(og.Authors, content)
+	case "article:section":
+		setOnce(&og.Section, content)
+	case "article:tag":
+		og.Tags = appendUnique(og.Tags, content)
+	}
+}
+
+// script は、<script type="application/ld+json"> の内容をJSONとして解析します。
+func (p *pageParser) script(n *html.Node) {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+	}
+	var v any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(sb.String())), &v); err != nil {
+		return
+	}
+	p.jsonLD = append(p.jsonLD, v)
+}
+
+// findNewsArticle は、JSON-LD のノード (@graph を含む) から記事を表すノードを探して NewsArticle に変換します。
+// NewsArticle 系の型 (ReportageNewsArticle などを含む) を優先し、ない場合は最初の Article・BlogPosting 系のノードを使用します。
+func findNewsArticle(docs []any) *NewsArticle {
+	var candidates []map[string]any
+	var collect func(v any)
+	collect = func(v any) {
+		switch v := v.(type) {
+		case []any:
+			for _, e := range v {
+				collect(e)
+			}
+		case map[string]any:
+			if articleType(v["@type"]) != "" {
+				candidates = append(candidates, v)
+			}
+			if graph, ok := v["@graph"]; ok {
+				collect(graph)
+			}
+		}
+	}
+	for _, doc := range docs {
+		collect(doc)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	node := candidates[0]
+	for _, c := range candidates {
+		if strings.HasSuffix(articleType(c["@type"]), "NewsArticle") {
+			node = c
+			break
+		}
+	}
+
+	article := &NewsArticle{
+		Type:          articleType(node["@type"]),
+		Headline:      first(names(node["headline"])),
+		Description:   first(names(node["description"])),
+		URL:           first(names(node["url"])),
+		Authors:       names(node["author"]),
+		Publisher:     first(names(node["publisher"])),
+		DatePublished: parseTime(first(names(node["datePublished"]))),
+		DateModified:  parseTime(first(names(node["dateModified"]))),
+		Section:       first(names(node["articleSection"])),
+		Lang:          first(names(node["inLanguage"])),
+	}
+	if keywords, ok := node["keywords"].(string); ok {
+		// keywords はカンマ区切りの文字列で記述されることが多い
+		for _, k := range strings.Split(keywords, ",") {
+			article.Keywords = appendUnique(article.Keywords, strings.TrimSpace(k))
+		}
+	} else {
+		article.Keywords = names(node["keywords"])
+	}
+	return article
+}
+
+// articleType は、@type (文字列または配列) が記事を表す型の場合にその型名を返し、それ以外の場合は空文字列を返します。
+// "https://schema.org/NewsArticle" のようなURL形式の型名は、末尾の型名として扱います。
+func articleType(v any) string {
+	for _, t := range names(v) {
+		if i := strings.LastIndexAny(t, "/:"); i >= 0 {
+			t = t[i+1:]
+		}
+		if strings.HasSuffix(t, "Article") || strings.HasSuffix(t, "BlogPosting") {
+			return t
+		}
+	}
+	return ""
+}
+
+// names は、JSON-LD の値 (文字列、name を持つオブジェクト、またはそれらの配列) から文字列の一覧を返します。
+func names(v any) []string {
+	var out []string
+	switch v := v.(type) {
+	case string:
+		out = appendUnique(out, strings.TrimSpace(v))
+	case map[string]any:
+		for _, key := range []string{"name", "@value"} {
+			if s, ok := v[key].(string); ok && strings.TrimSpace(s) != "" {
+				out = appendUnique(out, strings.TrimSpace(s))
+				break
+			}
+		}
+	case []any:
+		for _, e := range v {
+			for _, s := range names(e) {
+				out = appendUnique(out, s)
+			}
+		}
+	}
+	return out
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// parseTime は、ISO 8601 の日時を解析します。解析できない場合はゼロ値を返します。
+func parseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// resolveURL は、ref を pageURL を基準に絶対URLに解決します。解決できない場合は ref をそのまま返します。
+func resolveURL(pageURL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasToken は、空白区切りのトークンの一覧 (rel 属性など) に token が含まれるかどうかを返します。
+func hasToken(list, token string) bool {
+	for _, t := range strings.Fields(list) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func setOnce(dst *string, value string) {
+	if *dst == "" {
+		*dst = value
+	}
+}
+
+func appendUnique(values []string, v string) []string {
+	if v == "" {
+		return values
+	}
+	for _, e := range values {
+		if e == v {
+			return values
+		}
+	}
+	return append(values, v)
+}
@@ -2,50 +2,155 @@ package builder
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
+	"github.com/shouni/web-text-pipe-go/pkg/article"
+	"github.com/shouni/web-text-pipe-go/pkg/charset"
+	"github.com/shouni/web-text-pipe-go/pkg/feedcache"
+	"github.com/shouni/web-text-pipe-go/pkg/hostlimit"
+	"github.com/shouni/web-text-pipe-go/pkg/metrics"
+	"github.com/shouni/web-text-pipe-go/pkg/normalize"
+	"github.com/shouni/web-text-pipe-go/pkg/pipeline"
+	"github.com/shouni/web-text-pipe-go/pkg/robots"
 	"github.com/shouni/web-text-pipe-go/pkg/runner"
+	"github.com/shouni/web-text-pipe-go/pkg/sitemap"
+	"github.com/shouni/web-text-pipe-go/pkg/tracing"
 
 	"github.com/shouni/go-http-kit/pkg/httpkit"
 	"github.com/shouni/go-web-exact/v2/pkg/extract"
 	"github.com/shouni/go-web-exact/v2/pkg/feed"
-	"github.com/shouni/go-web-exact/v2/pkg/scraper"
 )
 
+// Options は、Runner とその依存関係を構築するための設定値を保持します。
+type Options struct {
+	ClientTimeout time.Duration      // HTTPリクエストのタイムアウト
+	Concurrency   int                // 記事の最大並列抽出数
+	RetryPolicy   runner.RetryPolicy // 失敗URLに対するワークフロー層のリトライ戦略
+
+	// HostLimits は、ホストごとのレート制限と同時リクエスト数の上限です (ドメインごとの上書きを含む)。
+	// ゼロ値の場合は hostlimit パッケージのデフォルト値を使用します。
+	HostLimits hostlimit.Config
+
+	// RobotsUserAgent は、robots.txt の User-agent と照合するトークンです (空の場合は robots.DefaultUserAgent)。
+	RobotsUserAgent string
+	// IgnoreRobots が true の場合、robots.txt による除外と Crawl-delay の反映を行いません。
+	IgnoreRobots bool
+
+	// HTTPClient が設定されている場合、記事・フィード・サイトマップ・robots.txt の取得に使用します
+	// (fetchconf.Config.NewClient で構築した、User-Agent・ヘッダー・Cookie・プロキシを適用するクライアント)。
+	HTTPClient *http.Client
+
+	// Normalizer が設定されている場合、抽出した本文を正規化します (normalize.New で構築)。
+	Normalizer *normalize.Normalizer
+
+	// Pipeline が設定されている場合、Runner は抽出後の記事に後処理のステージを適用します (pipeline.Build で構築)。
+	Pipeline *pipeline.Pipeline
+
+	// FeedValidators が設定されている場合、フィードは ETag / Last-Modified による条件付きGETで取得されます。
+	FeedValidators feedcache.ValidatorStore
+
+	// Metrics が設定されている場合、Runner・ReliableScraper・HTTP取得のメトリクスを記録します。
+	Metrics *metrics.Metrics
+}
+
+// BuildFetcher は、記事・フィードの取得に使用する extract.Fetcher を構築します。
+// opts.HTTPClient が設定されている場合はそれを使用し、取得ごとのスパンとメトリクスを記録します。
+// Runner の実行では、RunnerConfig.ClientTimeout を取得1回ごとのタイムアウトとして適用します (runner.NewTimeoutFetcher)。
+// exact コマンドなどの単一URL抽出でも、scraper と同じ設定で取得するために使用します。
+func BuildFetcher(opts Options) extract.Fetcher {
+	var clientOpts []httpkit.ClientOption
+	if opts.HTTPClient != nil {
+		clientOpts = append(clientOpts, httpkit.WithHTTPClient(opts.HTTPClient))
+	}
+	fetcher := tracing.InstrumentFetcher(metrics.InstrumentFetcher(httpkit.New(opts.ClientTimeout, clientOpts...), opts.Metrics))
+	return runner.NewTimeoutFetcher(fetcher)
+}
+
 // BuildReliableScraperExecutor は、必要な依存関係をすべて構築し、
 // リトライ戦略を持つ ScraperExecutor (ReliableScraper) のインスタンスを返します。
-func BuildReliableScraperExecutor(clientTimeout time.Duration, concurrency int) (*runner.ReliableScraper, error) {
-	// HTTP クライアントを初期化
-	fetcher := httpkit.New(clientTimeout)
+func BuildReliableScraperExecutor(opts Options) (*runner.ReliableScraper, error) {
+	// HTTP クライアントを初期化 (取得ごとのスパンとメトリクスを記録する)
+	fetcher := BuildFetcher(opts)
 
-	// コアな抽出エンジンを初期化
-	extractor, err := extract.NewExtractor(fetcher)
+	// コアな抽出エンジンを初期化 (Shift_JIS などのページは UTF-8 に変換してから解析し、ページのメタデータを記録する)
+	extractor, err := extract.NewExtractor(article.NewFetcher(charset.NewFetcher(fetcher)))
 	if err != nil {
 		return nil, fmt.Errorf("Extractorの初期化エラー: %w", err)
 	}
 
-	// 並列実行とレート制限を担当するコアスクレイパーを初期化
-	coreScraper := scraper.NewParallelScraper(extractor, concurrency, scraper.DefaultScrapeRateLimit)
+	// 並列実行とホストごとのレート制限を担当するコアスクレイパーを初期化
+	limiter := hostlimit.NewLimiter(opts.HostLimits)
+	coreScraper := hostlimit.NewScraper(extractor, opts.Concurrency, limiter)
 
 	// リトライ戦略と遅延処理を担当する ReliableScraper を構築
-	return runner.NewReliableScraper(coreScraper, extractor), nil
+	// リトライによる単体抽出にも、並列抽出と同じホストごとの制限を適用する
+	reliableScraper := runner.NewReliableScraper(coreScraper, hostlimit.LimitExtractor(extractor, limiter), opts.RetryPolicy)
+	if opts.Metrics != nil {
+		reliableScraper.Metrics = opts.Metrics
+	}
+	if opts.Normalizer != nil {
+		reliableScraper.Normalizer = opts.Normalizer
+	}
+	if !opts.IgnoreRobots {
+		// robots.txt の取得ではステータスコードを扱うため、http.Client を直接使用する (プロキシなどの設定は記事の取得と共通)
+		client := opts.HTTPClient
+		if client == nil {
+			client = &http.Client{Timeout: opts.ClientTimeout}
+		}
+		checker := robots.NewChecker(client, opts.RobotsUserAgent, robots.DefaultCacheTTL)
+		// Crawl-delay はホストごとのレート制限に反映する
+		checker.OnCrawlDelay = limiter.SetCrawlDelay
+		reliableScraper.Robots = checker
+	}
+	return reliableScraper, nil
 }
 
 // BuildScraperRunner は、必要な設定値に基づいて、runner.Runnerの依存関係をすべて構築し、
 // Runnerインスタンスを返します。
-func BuildScraperRunner(clientTimeout time.Duration, concurrency int) (*runner.Runner, error) {
-	// HTTP クライアントを初期化
-	fetcher := httpkit.New(clientTimeout)
+func BuildScraperRunner(opts Options) (*runner.Runner, error) {
+	// HTTP クライアントを初期化 (取得ごとのスパンとメトリクスを記録する)
+	fetcher := BuildFetcher(opts)
 
-	// FeedParser を初期化
-	parser := feed.NewParser(fetcher)
+	// FeedParser と SitemapParser を初期化
+	var parser runner.FeedParser = feed.NewParser(fetcher)
+	if opts.FeedValidators != nil {
+		// 条件付きGETのヘッダーと検証子は、記事と同じクライアントのトランスポートをラップして扱う
+		// (リトライ・スパン・メトリクスは記事の取得と同じく BuildFetcher で記録する)
+		feedOpts := opts
+		feedOpts.HTTPClient = conditionalClient(opts)
+		parser = feedcache.NewConditionalParser(BuildFetcher(feedOpts), opts.FeedValidators)
+	}
+	sitemapParser := sitemap.NewParser(fetcher)
 
 	// ReliableScraperExecutor を構築
-	reliableScraperExecutor, err := BuildReliableScraperExecutor(clientTimeout, concurrency)
+	reliableScraperExecutor, err := BuildReliableScraperExecutor(opts)
 	if err != nil {
 		return nil, err
 	}
 
 	// Runner を初期化
-	return runner.NewRunner(parser, reliableScraperExecutor), nil
+	runnerInstance := runner.NewRunnerWithSources(
+		runner.NewFeedSource(parser),
+		runner.NewSitemapSource(sitemapParser),
+		reliableScraperExecutor,
+	)
+	if opts.Metrics != nil {
+		runnerInstance.Metrics = opts.Metrics
+	}
+	if opts.Pipeline != nil {
+		runnerInstance.Pipeline = opts.Pipeline
+	}
+	return runnerInstance, nil
+}
+
+// conditionalClient は、opts.HTTPClient の設定を引き継ぎ、トランスポートを feedcache.NewTransport でラップした http.Client を返します。
+func conditionalClient(opts Options) *http.Client {
+	client := &http.Client{Timeout: opts.ClientTimeout}
+	if opts.HTTPClient != nil {
+		copied := *opts.HTTPClient
+		client = &copied
+	}
+	client.Transport = feedcache.NewTransport(client.Transport)
+	return client
 }
@@ -0,0 +1,144 @@
+package charset
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+
+	htmlcharset "golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	unicodeenc "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// 検出結果として記録する主な文字コード名 (WHATWG Encoding Standard の名前) です。
+const (
+	UTF8      = "utf-8"
+	ShiftJIS  = "shift_jis"
+	EUCJP     = "euc-jp"
+	ISO2022JP = "iso-2022-jp"
+	// Windows1252 は、日本語の文字コードとして解釈できない場合のフォールバックです (WHATWG の既定値)。
+	Windows1252 = "windows-1252"
+)
+
+// metaScanBytes は、<meta charset> を探す先頭のバイト数です (WHATWG の prescan と同じ)。
+const metaScanBytes = 1024
+
+// metaCharsetPattern は、<meta charset="..."> と <meta http-equiv="Content-Type" content="...; charset=..."> に一致します。
+var metaCharsetPattern = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_\-:.]+)`)
+
+// boms は、BOM と対応する文字コードです。
+var boms = []struct {
+	bom  []byte
+	name string
+	enc  encoding.Encoding
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, UTF8, encoding.Nop},
+	{[]byte{0xFE, 0xFF}, "utf-16be", unicodeenc.UTF16(unicodeenc.BigEndian, unicodeenc.ExpectBOM)},
+	{[]byte{0xFF, 0xFE}, "utf-16le", unicodeenc.UTF16(unicodeenc.LittleEndian, unicodeenc.ExpectBOM)},
+}
+
+// Detect は、HTMLの文字コードを BOM、バイト列の統計、<meta charset> の順に判定し、文字コード名を返します。
+//   - BOM がある場合は BOM に従います。
+//   - 非ASCIIの文字を含む有効な UTF-8 の場合は、<meta charset> の宣言に関わらず UTF-8 とします
+//     (宣言と実際の文字コードが食い違うサイトが多いため)。
+//   - UTF-8 として無効な場合は、<meta charset> の宣言 (UTF-8 以外) に従います。
+//   - 宣言がない場合は、Shift_JIS と EUC-JP のうち、不正なバイト列が少なく日本語の文字が多い方とします。
+func Detect(body []byte) string {
+	name, _ := detect(body)
+	return name
+}
+
+// ToUTF8 は、HTMLの文字コードを判定して UTF-8 に変換し、変換後のバイト列と判定した文字コード名を返します。
+// UTF-8 の BOM は取り除きます。
+func ToUTF8(body []byte) ([]byte, string, error) {
+	name, enc := detect(body)
+	if enc == encoding.Nop {
+		return bytes.TrimPrefix(body, boms[0].bom), name, nil
+	}
+
+	decoded, _, err := transform.Bytes(enc.NewDecoder(), body)
+	if err != nil {
+		return nil, name, fmt.Errorf("文字コードの変換エラー (%s から UTF-8): %w", name, err)
+	}
+	return decoded, name, nil
+}
+
+// detect は、文字コード名と、UTF-8 に変換するための encoding.Encoding (UTF-8 の場合は encoding.Nop) を返します。
+func detect(body []byte) (string, encoding.Encoding) {
+	// 1. BOM
+	for _, b := range boms {
+		if bytes.HasPrefix(body, b.bom) {
+			return b.name, b.enc
+		}
+	}
+
+	// 2. ISO-2022-JP は7ビットのため、UTF-8 の判定より先にエスケープシーケンスで判定する
+	if bytes.Contains(body, []byte("\x1b$B")) || bytes.Contains(body, []byte("\x1b$@")) {
+		return ISO2022JP, japanese.ISO2022JP
+	}
+
+	// 3. UTF-8 (ASCII のみの場合を含む)
+	if utf8.Valid(body) {
+		return UTF8, encoding.Nop
+	}
+
+	// 4. <meta charset> の宣言 (UTF-8 と宣言されていても UTF-8 として無効な場合は統計で判定する)
+	head := body[:min(len(body), metaScanBytes)]
+	if m := metaCharsetPattern.FindSubmatch(head); m != nil {
+		if enc, name := htmlcharset.Lookup(string(m[1])); enc != nil && name != UTF8 && name != "utf-16be" && name != "utf-16le" {
+			return name, enc
+		}
+	}
+
+	// 5. バイト列の統計
+	return detectJapanese(body)
+}
+
+// detectJapanese は、Shift_JIS と EUC-JP でそれぞれ変換した結果を比較し、
+// 不正なバイト列 (置換文字) が少なく、日本語の文字 (かな・漢字) が多い方を返します。
+// どちらでも日本語の文字が見つからない場合は Windows-1252 とします。
+func detectJapanese(body []byte) (string, encoding.Encoding) {
+	candidates := []struct {
+		name string
+		enc  encoding.Encoding
+	}{
+		{ShiftJIS, japanese.ShiftJIS},
+		{EUCJP, japanese.EUCJP},
+	}
+
+	bestName, bestEnc := Windows1252, encoding.Encoding(charmap.Windows1252)
+	bestInvalid, bestJapanese := -1, 0
+	for _, c := range candidates {
+		decoded, _, err := transform.Bytes(c.enc.NewDecoder(), body)
+		if err != nil {
+			continue
+		}
+		invalid, japaneseCount := score(decoded)
+		if japaneseCount == 0 {
+			continue
+		}
+		if bestInvalid < 0 || invalid < bestInvalid || (invalid == bestInvalid && japaneseCount > bestJapanese) {
+			bestName, bestEnc = c.name, c.enc
+			bestInvalid, bestJapanese = invalid, japaneseCount
+		}
+	}
+	return bestName, bestEnc
+}
+
+// score は、変換後の文字列に含まれる置換文字の数と、日本語の文字 (ひらがな・カタカナ・漢字) の数を返します。
+func score(decoded []byte) (invalid, japaneseCount int) {
+	for _, r := range string(decoded) {
+		switch {
+		case r == utf8.RuneError:
+			invalid++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han):
+			japaneseCount++
+		}
+	}
+	return invalid, japaneseCount
+}
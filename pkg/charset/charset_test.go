@@ -0,0 +1,79 @@
+package charset
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// encode は、UTF-8 の文字列を enc で符号化します。
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	return b
+}
+
+func TestDetect(t *testing.T) {
+	const text = "<html><body><p>日本語のページです。ひらがな、カタカナ、漢字を含みます。</p></body></html>"
+	const sjisMeta = `<html><head><meta http-equiv="Content-Type" content="text/html; charset=Shift_JIS"></head><body>ｱｲｳ</body></html>`
+
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{"ASCII のみ", []byte("<html><body>hello</body></html>"), UTF8},
+		{"UTF-8", []byte(text), UTF8},
+		{"UTF-8 (宣言は Shift_JIS)", []byte(`<meta charset="Shift_JIS">` + text), UTF8},
+		{"UTF-8 の BOM", append([]byte{0xEF, 0xBB, 0xBF}, text...), UTF8},
+		{"UTF-16LE の BOM", append([]byte{0xFF, 0xFE}, encode(t, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), text)...), "utf-16le"},
+		{"UTF-16BE の BOM", append([]byte{0xFE, 0xFF}, encode(t, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), text)...), "utf-16be"},
+		{"Shift_JIS (宣言なし)", encode(t, japanese.ShiftJIS, text), ShiftJIS},
+		{"EUC-JP (宣言なし)", encode(t, japanese.EUCJP, text), EUCJP},
+		{"ISO-2022-JP", encode(t, japanese.ISO2022JP, text), ISO2022JP},
+		{"Shift_JIS の宣言に従う", encode(t, japanese.ShiftJIS, sjisMeta), ShiftJIS},
+		{"EUC-JP の宣言に従う", encode(t, japanese.EUCJP, `<meta charset="euc-jp">`+text), EUCJP},
+		{"UTF-8 と宣言された Shift_JIS", encode(t, japanese.ShiftJIS, `<meta charset="utf-8">`+text), ShiftJIS},
+		{"日本語を含まない不正なバイト列", []byte{'c', 'a', 'f', 0xE9}, Windows1252},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.body); got != tt.want {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToUTF8(t *testing.T) {
+	const text = "<p>文字化けしない日本語の本文</p>"
+
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{"UTF-8 の BOM を取り除く", append([]byte{0xEF, 0xBB, 0xBF}, text...), UTF8},
+		{"Shift_JIS", encode(t, japanese.ShiftJIS, text), ShiftJIS},
+		{"EUC-JP", encode(t, japanese.EUCJP, text), EUCJP},
+		{"UTF-16LE の BOM", append([]byte{0xFF, 0xFE}, encode(t, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), text)...), "utf-16le"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, name, err := ToUTF8(tt.body)
+			if err != nil {
+				t.Fatalf("ToUTF8() error = %v", err)
+			}
+			if name != tt.want || string(got) != text {
+				t.Errorf("ToUTF8() = %q, %q, want %q, %q", got, name, text, tt.want)
+			}
+		})
+	}
+}
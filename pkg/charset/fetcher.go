@@ -0,0 +1,69 @@
+package charset
+
+import (
+	"context"
+	"sync"
+
+	"github.com/shouni/go-web-exact/v2/pkg/extract"
+)
+
+// Recorder は、取得したページごとに判定した文字コードを記録します。
+// WithRecorder でコンテキストに設定すると、NewFetcher の Fetcher が取得のたびに記録します。
+type Recorder struct {
+	mu       sync.Mutex
+	charsets map[string]string
+}
+
+// NewRecorder は、空の Recorder を初期化します。
+func NewRecorder() *Recorder {
+	return &Recorder{charsets: make(map[string]string)}
+}
+
+// Charset は、URLのページについて判定した文字コードを返します。取得していない場合は空文字列を返します。
+func (r *Recorder) Charset(url string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.charsets[url]
+}
+
+func (r *Recorder) set(url, charset string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.charsets[url] = charset
+}
+
+// recorderKey は、Recorder をコンテキストに格納するためのキーです。
+type recorderKey struct{}
+
+// WithRecorder は、文字コードの記録先を設定したコンテキストを返します。
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// transcodingFetcher は、取得したHTMLの文字コードを判定し、UTF-8 に変換して返す extract.Fetcher です。
+type transcodingFetcher struct {
+	fetcher extract.Fetcher
+}
+
+// NewFetcher は、取得したHTMLを UTF-8 に変換して返す extract.Fetcher を返します。
+// extract.Extractor は HTML を UTF-8 として解析するため、Shift_JIS などのページの文字化けを防ぐために使用します。
+// フィード・サイトマップ (XML) は XML 宣言で文字コードを扱うため、この Fetcher を使用しないでください。
+func NewFetcher(fetcher extract.Fetcher) extract.Fetcher {
+	return &transcodingFetcher{fetcher: fetcher}
+}
+
+func (f *transcodingFetcher) FetchBytes(ctx context.Context, url string) ([]byte, error) {
+	body, err := f.fetcher.FetchBytes(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, name, err := ToUTF8(body)
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := ctx.Value(recorderKey{}).(*Recorder); ok {
+		r.set(url, name)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,247 @@
+package feedcache
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/shouni/go-http-kit/pkg/httpkit"
+)
+
+// maxFeedBytes は、読み込むフィードの最大サイズです。
+const maxFeedBytes = 20 * 1024 * 1024
+
+// CustomKeyTTL は、RSS の <ttl> (分) を gofeed.Feed.Custom に格納する際のキーです。
+const CustomKeyTTL = "ttl"
+
+// ErrNotModified は、フィードが前回の取得から更新されていない (HTTP 304) ことを表します。
+var ErrNotModified = errors.New("フィードは前回の取得から更新されていません")
+
+// Validators は、条件付きリクエストに使用するレスポンスの検証子です。
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// IsZero は、検証子が一つも記録されていないかを判定します。
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// ValidatorStore は、フィードURLごとの検証子の保存と参照機能を提供します。
+type ValidatorStore interface {
+	GetValidators(feedURL string) (Validators, error)
+	SetValidators(feedURL string, v Validators) error
+}
+
+// Fetcher は、指定URLのレスポンスボディを取得する機能の抽象化です。
+// httpkit.Client (およびメトリクス・トレースを記録するラッパー) がこのインターフェースを満たします。
+// 条件付きGETのヘッダーの付与とレスポンスの検証子の取得は、NewTransport のトランスポートが行うため、
+// Fetcher の HTTP クライアントには NewTransport でラップしたトランスポートを設定してください。
+type Fetcher interface {
+	FetchBytes(ctx context.Context, url string) ([]byte, error)
+}
+
+// ConditionalParser は、ETag / Last-Modified を用いた条件付きGETでフィードを取得・解析します。
+// runner.FeedParser インターフェースを満たします。
+//
+// 取得したフィードの検証子はすぐには保存せず、CommitValidators が呼び出された時点で保存します。
+// 記事の抽出に失敗した場合や処理が中断された場合に、次回の取得が 304 Not Modified となって
+// 未処理の記事を取りこぼさないよう、呼び出し元 (runner.Runner) が記事の処理の完了後に保存します。
+type ConditionalParser struct {
+	fetcher Fetcher
+	store   ValidatorStore
+
+	mu      sync.Mutex
+	pending map[string]Validators // 取得済みで未保存の検証子
+}
+
+// NewConditionalParser は、Fetcher と検証子のストアを注入して ConditionalParser を初期化します。
+func NewConditionalParser(fetcher Fetcher, store ValidatorStore) *ConditionalParser {
+	return &ConditionalParser{
+		fetcher: fetcher,
+		store:   store,
+		pending: make(map[string]Validators),
+	}
+}
+
+// FetchAndParse は、前回の検証子を付与してフィードを取得し、解析します。
+// サーバーが 304 Not Modified を返した場合は ErrNotModified を返します。
+// ctx に WithoutValidators が設定されている場合は、検証子を付与せずに取得します。
+func (p *ConditionalParser) FetchAndParse(ctx context.Context, feedURL string) (*gofeed.Feed, error) {
+	ex := &exchange{}
+	if unconditional, _ := ctx.Value(unconditionalKey{}).(bool); !unconditional {
+		validators, err := p.store.GetValidators(feedURL)
+		if err != nil {
+			// 検証子を参照できない場合は、通常のGETとして続行する
+			slog.Warn("フィードの検証子を取得できませんでした", slog.String("feed_url", feedURL), slog.Any("error", err))
+			validators = Validators{}
+		}
+		ex.request = validators
+	}
+
+	body, err := p.fetcher.FetchBytes(context.WithValue(ctx, exchangeKey{}, ex), feedURL)
+	var httpErr *httpkit.NonRetryableHTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotModified {
+		return nil, ErrNotModified
+	}
+	if err != nil {
+		return nil, fmt.Errorf("フィードの取得エラー (URL: %s): %w", feedURL, err)
+	}
+	if len(body) > maxFeedBytes {
+		// NewTransport は上限を1バイト超えた時点で読み込みを打ち切る。途中で切り詰めたXMLは解析できないため、エラーとする
+		return nil, fmt.Errorf("フィードのサイズが上限 (%d バイト) を超えています (URL: %s)", maxFeedBytes, feedURL)
+	}
+
+	// gofeed.Parser は並行利用できないため、呼び出しごとに生成する
+	parsedFeed, err := gofeed.NewParser().ParseString(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("フィードの解析エラー (URL: %s): %w", feedURL, err)
+	}
+
+	// gofeed の共通フィード型は RSS の <ttl> を保持しないため、Custom に格納して更新間隔の判定に使えるようにする
+	if ttl := rssTTL(body); ttl != "" {
+		if parsedFeed.Custom == nil {
+			parsedFeed.Custom = make(map[string]string)
+		}
+		parsedFeed.Custom[CustomKeyTTL] = ttl
+	}
+
+	p.mu.Lock()
+	if ex.response.IsZero() {
+		delete(p.pending, feedURL)
+	} else {
+		p.pending[feedURL] = ex.response
+	}
+	p.mu.Unlock()
+
+	return parsedFeed, nil
+}
+
+// CommitValidators は、FetchAndParse で取得したフィードの検証子を保存します。
+// フィードの記事をすべて処理し終えた後に呼び出します。未保存の検証子がない場合は何もしません。
+func (p *ConditionalParser) CommitValidators(feedURL string) error {
+	p.mu.Lock()
+	validators, ok := p.pending[feedURL]
+	delete(p.pending, feedURL)
+	p.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return p.store.SetValidators(feedURL, validators)
+}
+
+// unconditionalKey は、検証子を付与しない取得の指定をコンテキストに格納するためのキーです。
+type unconditionalKey struct{}
+
+// WithoutValidators は、FetchAndParse が検証子を付与せずにフィードを取得するコンテキストを返します。
+// 抽出済みの記事も再抽出する場合 (--force) に、更新のないフィードが 304 Not Modified とならないようにするために使用します。
+func WithoutValidators(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unconditionalKey{}, true)
+}
+
+// exchange は、1回のフィードの取得で送信する検証子と、レスポンスで受け取った検証子です。
+type exchange struct {
+	request  Validators
+	response Validators
+}
+
+// exchangeKey は、exchange をコンテキストに格納するためのキーです。
+type exchangeKey struct{}
+
+// conditionalTransport は、ConditionalParser の取得に検証子のヘッダーを付与し、レスポンスの検証子を記録する http.RoundTripper です。
+type conditionalTransport struct {
+	base http.RoundTripper
+}
+
+// NewTransport は、ConditionalParser による取得のリクエストに If-None-Match / If-Modified-Since を付与し、
+// レスポンスの ETag / Last-Modified を記録する http.RoundTripper を返します。
+// 上限を超えるフィードの全体を読み込まないよう、レスポンスボディの読み込みを上限 + 1バイトまでに制限します。
+// それ以外のリクエストはそのまま base に渡します。base が nil の場合は http.DefaultTransport を使用します。
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &conditionalTransport{base: base}
+}
+
+func (t *conditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ex, ok := req.Context().Value(exchangeKey{}).(*exchange)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	// RoundTripper はリクエストを変更してはならないため、複製してヘッダーを付与する
+	req = req.Clone(req.Context())
+	if ex.request.ETag != "" {
+		req.Header.Set("If-None-Match", ex.request.ETag)
+	}
+	if ex.request.LastModified != "" {
+		req.Header.Set("If-Modified-Since", ex.request.LastModified)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		ex.response = Validators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+	}
+	// 上限を1バイト超えて読み込めた場合に、FetchAndParse が上限超過として扱う
+	resp.Body = &limitedBody{Reader: io.LimitReader(resp.Body, maxFeedBytes+1), Closer: resp.Body}
+	return resp, nil
+}
+
+// limitedBody は、読み込みを制限したレスポンスボディです。Close は元のボディを閉じます。
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// rssTTL は、RSS 2.0 の <channel><ttl> の値を返します。RSS 以外や宣言がない場合は空文字列を返します。
+func rssTTL(body []byte) string {
+	var doc struct {
+		XMLName xml.Name
+		TTL     string `xml:"channel>ttl"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil || doc.XMLName.Local != "rss" {
+		return ""
+	}
+	return strings.TrimSpace(doc.TTL)
+}
+
+// MemoryStore は、プロセス内で検証子を保持する ValidatorStore の実装です。
+type MemoryStore struct {
+	mu         sync.Mutex
+	validators map[string]Validators
+}
+
+// NewMemoryStore は、空の MemoryStore を初期化します。
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{validators: make(map[string]Validators)}
+}
+
+// GetValidators は、フィードURLの検証子を返します。記録がない場合はゼロ値を返します。
+func (s *MemoryStore) GetValidators(feedURL string) (Validators, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.validators[feedURL], nil
+}
+
+// SetValidators は、フィードURLの検証子を記録します。
+func (s *MemoryStore) SetValidators(feedURL string, v Validators) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validators[feedURL] = v
+	return nil
+}
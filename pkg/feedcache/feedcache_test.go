@@ -0,0 +1,91 @@
+package feedcache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shouni/go-http-kit/pkg/httpkit"
+)
+
+const testFeed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>テスト</title><ttl>60</ttl>
+<item><title>記事1</title><link>https://example.com/1</link></item>
+</channel></rss>`
+
+// newTestParser は、NewTransport でラップしたクライアントを使用する ConditionalParser を返します。
+func newTestParser(store ValidatorStore) *ConditionalParser {
+	client := &http.Client{Transport: NewTransport(nil), Timeout: 10 * time.Second}
+	return NewConditionalParser(httpkit.New(10*time.Second, httpkit.WithHTTPClient(client), httpkit.WithMaxRetries(0)), store)
+}
+
+func TestConditionalParserNotModified(t *testing.T) {
+	const etag = `"v1"`
+	var gotIfNoneMatch []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIfNoneMatch = append(gotIfNoneMatch, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(testFeed))
+	}))
+	defer srv.Close()
+
+	store := NewMemoryStore()
+	p := newTestParser(store)
+	ctx := context.Background()
+
+	feed, err := p.FetchAndParse(ctx, srv.URL)
+	if err != nil {
+		t.Fatalf("FetchAndParse() error = %v", err)
+	}
+	if len(feed.Items) != 1 || feed.Custom[CustomKeyTTL] != "60" {
+		t.Errorf("feed = %d items, ttl %q, want 1 item, ttl %q", len(feed.Items), feed.Custom[CustomKeyTTL], "60")
+	}
+
+	// 検証子は CommitValidators の呼び出しまで保存しない
+	if v, _ := store.GetValidators(srv.URL); !v.IsZero() {
+		t.Fatalf("validators saved before commit: %+v", v)
+	}
+	if err := p.CommitValidators(srv.URL); err != nil {
+		t.Fatalf("CommitValidators() error = %v", err)
+	}
+
+	if _, err := p.FetchAndParse(ctx, srv.URL); !errors.Is(err, ErrNotModified) {
+		t.Errorf("FetchAndParse() error = %v, want ErrNotModified", err)
+	}
+	// WithoutValidators の場合は検証子を付与しない
+	if _, err := p.FetchAndParse(WithoutValidators(ctx), srv.URL); err != nil {
+		t.Errorf("FetchAndParse(WithoutValidators) error = %v", err)
+	}
+
+	want := []string{"", etag, ""}
+	if strings.Join(gotIfNoneMatch, ",") != strings.Join(want, ",") {
+		t.Errorf("If-None-Match = %q, want %q", gotIfNoneMatch, want)
+	}
+}
+
+func TestConditionalParserTooLarge(t *testing.T) {
+	// 上限を超えても送信を続けるサーバー (クライアントが読み込みを打ち切ると書き込みに失敗して終了する)
+	chunk := []byte(strings.Repeat("<!-- padding -->", 4096))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel>`))
+		for written := 0; written < 4*maxFeedBytes; written += len(chunk) {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	_, err := newTestParser(NewMemoryStore()).FetchAndParse(context.Background(), srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "フィードのサイズが上限") {
+		t.Errorf("FetchAndParse() error = %v, want size limit error", err)
+	}
+}
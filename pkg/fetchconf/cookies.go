@@ -0,0 +1,87 @@
+package fetchconf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpOnlyPrefix は、Netscape 形式の cookies.txt で HttpOnly の Cookie を表す行頭の接頭辞です。
+const httpOnlyPrefix = "#HttpOnly_"
+
+// LoadCookies は、Netscape 形式の cookies.txt (ブラウザの拡張機能や curl -c が出力する形式) を読み込み、
+// 有効期限切れでない Cookie を jar に設定します。
+func LoadCookies(jar http.CookieJar, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Cookie ファイルのオープンエラー (%s): %w", path, err)
+	}
+	defer f.Close()
+
+	if err := ReadCookies(jar, f, time.Now()); err != nil {
+		return fmt.Errorf("Cookie ファイルの読み込みエラー (%s): %w", path, err)
+	}
+	return nil
+}
+
+// ReadCookies は、Netscape 形式の Cookie を読み込み、now の時点で有効期限切れでない Cookie を jar に設定します。
+// 各行はタブ区切りで「ドメイン, サブドメインを含むか, パス, Secure, 有効期限 (Unix秒, 0 はセッション), 名前, 値」です。
+func ReadCookies(jar http.CookieJar, r io.Reader, now time.Time) error {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r\n")
+
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		if httpOnly {
+			line = strings.TrimPrefix(line, httpOnlyPrefix)
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("%d行目: Netscape 形式の Cookie として解析できません (タブ区切りの7項目が必要です)", lineNo)
+		}
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%d行目: 有効期限の解析エラー: %w", lineNo, err)
+		}
+
+		host := strings.TrimPrefix(fields[0], ".")
+		secure := strings.EqualFold(fields[3], "TRUE")
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   secure,
+			HttpOnly: httpOnly,
+		}
+		// サブドメインを含む Cookie は Domain 属性を持つ Cookie として、それ以外はホスト限定の Cookie として設定する
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = host
+		}
+		if expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+			if cookie.Expires.Before(now) {
+				continue
+			}
+		}
+
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: cookie.Path}, []*http.Cookie{cookie})
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,114 @@
+package fetchconf
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCookies(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	const cookies = "# Netscape HTTP Cookie File\n" +
+		"\n" +
+		".example.com\tTRUE\t/\tFALSE\t0\tsession\tabc\n" +
+		"#HttpOnly_www.example.com\tFALSE\t/\tTRUE\t1800000000\tsecret\txyz\n" +
+		"www.example.com\tFALSE\t/app\tFALSE\t1600000000\texpired\told\r\n"
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadCookies(jar, strings.NewReader(cookies), now); err != nil {
+		t.Fatalf("ReadCookies() error = %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want map[string]string
+	}{
+		// サブドメインを含む Cookie と、HttpOnly の Secure な Cookie (https のみ)
+		{"https://www.example.com/", map[string]string{"session": "abc", "secret": "xyz"}},
+		{"http://www.example.com/", map[string]string{"session": "abc"}},
+		// ホスト限定の Cookie は他のサブドメインに送信しない
+		{"https://api.example.com/", map[string]string{"session": "abc"}},
+		// 有効期限切れの Cookie は設定しない
+		{"http://www.example.com/app/", map[string]string{"session": "abc"}},
+		{"https://other.test/", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			got := cookieMap(jar.Cookies(u))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Cookies(%s) = %v, want %v", tt.url, got, tt.want)
+			}
+			for name, value := range tt.want {
+				if got[name] != value {
+					t.Errorf("Cookies(%s)[%s] = %q, want %q", tt.url, name, got[name], value)
+				}
+			}
+		})
+	}
+}
+
+func TestReadCookiesHttpOnly(t *testing.T) {
+	var jar recordingJar
+	line := "#HttpOnly_.example.com\tTRUE\t/\tFALSE\t0\tid\t1\n"
+	if err := ReadCookies(&jar, strings.NewReader(line), time.Now()); err != nil {
+		t.Fatalf("ReadCookies() error = %v", err)
+	}
+	if len(jar.cookies) != 1 {
+		t.Fatalf("cookies = %d, want 1", len(jar.cookies))
+	}
+	c := jar.cookies[0]
+	if !c.HttpOnly || c.Domain != "example.com" || c.Name != "id" {
+		t.Errorf("cookie = %+v, want HttpOnly cookie id for domain example.com", c)
+	}
+}
+
+func TestReadCookiesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookies string
+		wantErr string
+	}{
+		{"項目数の不足", "example.com\tFALSE\t/\tFALSE\t0\tname\n", "1行目"},
+		{"有効期限が数値でない", "# comment\nexample.com\tFALSE\t/\tFALSE\tnever\tname\tvalue\n", "2行目"},
+		{"HttpOnly の項目数の不足", "#HttpOnly_example.com\tFALSE\t/\n", "1行目"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jar, _ := cookiejar.New(nil)
+			err := ReadCookies(jar, strings.NewReader(tt.cookies), time.Now())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadCookies() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// recordingJar は、設定された Cookie をそのまま記録する http.CookieJar です。
+type recordingJar struct {
+	cookies []*http.Cookie
+}
+
+func (j *recordingJar) SetCookies(_ *url.URL, cookies []*http.Cookie) {
+	j.cookies = append(j.cookies, cookies...)
+}
+
+func (j *recordingJar) Cookies(*url.URL) []*http.Cookie {
+	return j.cookies
+}
+
+func cookieMap(cookies []*http.Cookie) map[string]string {
+	m := make(map[string]string, len(cookies))
+	for _, c := range cookies {
+		m[c.Name] = c.Value
+	}
+	return m
+}
@@ -0,0 +1,160 @@
+package fetchconf
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Config は、記事・フィードを取得するHTTPクライアントの設定です。
+// exact・scraper などすべてのコマンドで共有し、NewClient で http.Client を構築します。
+type Config struct {
+	UserAgent   string            `json:"user_agent,omitempty"`   // すべてのリクエストの User-Agent (空の場合は httpkit の既定値)
+	Headers     map[string]string `json:"headers,omitempty"`      // すべてのリクエストに付加するヘッダー
+	CookiesFile string            `json:"cookies_file,omitempty"` // Cookie を読み込む Netscape 形式の cookies.txt
+
+	// Proxies は、すべてのリクエストに使用するプロキシURL (http / https / socks5) のプールです。
+	// 空の場合は環境変数 (HTTP_PROXY / HTTPS_PROXY / NO_PROXY) の設定に従います。
+	Proxies   []string `json:"proxies,omitempty"`
+	ProxyMode string   `json:"proxy_mode,omitempty"` // プロキシの選択方式 (ProxyRoundRobin / ProxySticky)
+
+	// Domains は、ドメインごとの設定です。キーのドメインとそのサブドメインに適用され、
+	// 複数のキーに一致する場合は最も長い (具体的な) ドメインを優先します。
+	Domains map[string]DomainConfig `json:"domains,omitempty"`
+}
+
+// DomainConfig は、特定のドメインへのリクエストに適用する設定です。
+// グローバルな設定に上書き (ヘッダーは同名のもののみ上書き) して適用されます。
+type DomainConfig struct {
+	UserAgent string            `json:"user_agent,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// Load は、JSON 形式の設定ファイルを読み込みます。
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("HTTP設定ファイルの読み込みエラー (%s): %w", path, err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("HTTP設定ファイルのJSONデコードエラー (%s): %w", path, err)
+	}
+
+	// ドメインの照合は小文字で行う
+	domains := make(map[string]DomainConfig, len(config.Domains))
+	for domain, dc := range config.Domains {
+		domains[strings.ToLower(strings.TrimSuffix(domain, "."))] = dc
+	}
+	config.Domains = domains
+	return config, nil
+}
+
+// ParseHeader は、"Name: value" 形式のヘッダー指定を解析します。
+func ParseHeader(header string) (name, value string, err error) {
+	name, value, found := strings.Cut(header, ":")
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return "", "", fmt.Errorf("無効なヘッダーの指定です。\"Name: value\" の形式で指定してください (指定値: %q)", header)
+	}
+	return name, strings.TrimSpace(value), nil
+}
+
+// NewClient は、設定を適用した http.Client を構築します。
+// ヘッダーはリクエストごと (リダイレクト先を含む) に宛先のドメインに応じて設定し、
+// CookiesFile が指定されている場合は、読み込んだ Cookie を持つ Cookie Jar を使用します。
+// Proxies が指定されている場合は、ProxyMode に従ってプールから選択したプロキシを経由します。
+func (c Config) NewClient(timeout time.Duration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(c.Proxies) > 0 {
+		pool, err := newProxyPool(c.Proxies, c.ProxyMode)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = pool.Proxy
+	}
+
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return nil, fmt.Errorf("Cookie Jar の初期化エラー: %w", err)
+	}
+	if c.CookiesFile != "" {
+		if err := LoadCookies(jar, c.CookiesFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Jar:       jar,
+		Transport: &headerTransport{base: transport, config: c},
+	}, nil
+}
+
+// headersFor は、ホストへのリクエストに適用する User-Agent とヘッダーを返します。
+func (c Config) headersFor(host string) (userAgent string, headers map[string]string) {
+	userAgent = c.UserAgent
+	headers = c.Headers
+
+	dc, ok := c.domainFor(host)
+	if !ok {
+		return userAgent, headers
+	}
+	if dc.UserAgent != "" {
+		userAgent = dc.UserAgent
+	}
+	if len(dc.Headers) > 0 {
+		headers = maps.Clone(c.Headers)
+		if headers == nil {
+			headers = make(map[string]string, len(dc.Headers))
+		}
+		maps.Copy(headers, dc.Headers)
+	}
+	return userAgent, headers
+}
+
+// domainFor は、ホスト名そのもの、次に親ドメインの順にドメインごとの設定を探します (最も具体的なものを優先)。
+func (c Config) domainFor(host string) (DomainConfig, bool) {
+	for domain := strings.ToLower(host); domain != ""; {
+		if dc, ok := c.Domains[domain]; ok {
+			return dc, true
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return DomainConfig{}, false
+}
+
+// headerTransport は、宛先のドメインに応じた User-Agent とヘッダーを設定する http.RoundTripper です。
+type headerTransport struct {
+	base   http.RoundTripper
+	config Config
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	userAgent, headers := t.config.headersFor(req.URL.Hostname())
+	if userAgent == "" && len(headers) == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	// RoundTripper は受け取ったリクエストを変更してはならないため、複製してからヘッダーを設定する
+	req = req.Clone(req.Context())
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,244 @@
+package hostlimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shouni/go-web-exact/v2/pkg/scraper"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultInterval は、同一ホストへのリクエストのデフォルトの最小間隔です (トークンの補充間隔)。
+	DefaultInterval = scraper.DefaultScrapeRateLimit
+	// DefaultBurst は、同一ホストへのリクエストのデフォルトのバーストサイズです。
+	DefaultBurst = 1
+	// DefaultMaxInFlight は、同一ホストへの同時リクエスト数のデフォルトの上限です。
+	DefaultMaxInFlight = 2
+)
+
+// Limit は、1ホストあたりのレート制限と同時リクエスト数の上限です。
+// 0 のフィールドは、Config.Default (上書き設定の場合) またはデフォルト値を引き継ぎます。
+type Limit struct {
+	Interval    time.Duration // リクエストの最小間隔 (トークンバケットの補充間隔)
+	Burst       int           // バーストサイズ (事前に貯められるトークン数)
+	MaxInFlight int           // 同時リクエスト数の上限
+}
+
+// Config は、ホストごとの制限の設定です。
+type Config struct {
+	Default Limit // すべてのホストに適用する制限
+
+	// Overrides は、ドメインごとの制限の上書きです。キーのドメインとそのサブドメインに適用され、
+	// 複数のキーに一致する場合は最も長い (具体的な) ドメインを優先します。
+	Overrides map[string]Limit
+}
+
+// merge は、l の0のフィールドを base の値で補完した Limit を返します。
+func (l Limit) merge(base Limit) Limit {
+	if l.Interval == 0 {
+		l.Interval = base.Interval
+	}
+	if l.Burst == 0 {
+		l.Burst = base.Burst
+	}
+	if l.MaxInFlight == 0 {
+		l.MaxInFlight = base.MaxInFlight
+	}
+	return l
+}
+
+// Validate は、Limit に負の値が含まれていないかを検証します。
+func (l Limit) Validate() error {
+	if l.Interval < 0 || l.Burst < 0 || l.MaxInFlight < 0 {
+		return fmt.Errorf("ホストごとの制限には 0 以上の値を指定してください (interval: %s, burst: %d, max-in-flight: %d)", l.Interval, l.Burst, l.MaxInFlight)
+	}
+	return nil
+}
+
+// limitFor は、ホストに適用する制限を返します。
+func (c Config) limitFor(host string) Limit {
+	base := c.Default.merge(Limit{Interval: DefaultInterval, Burst: DefaultBurst, MaxInFlight: DefaultMaxInFlight})
+
+	// ホスト名そのもの、次に親ドメインの順に上書き設定を探す (最も具体的なものを優先)
+	for domain := host; domain != ""; {
+		if override, ok := c.Overrides[domain]; ok {
+			return override.merge(base)
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return base
+}
+
+// --- 上書き設定の解析 ---
+
+// ParseRule は、"news.example.com interval=2s burst=1 max-in-flight=1" 形式の上書き設定を解析します。
+// 指定されていない項目は Config.Default の値を引き継ぎます。
+func ParseRule(rule string) (string, Limit, error) {
+	fields := strings.Fields(rule)
+	if len(fields) < 2 {
+		return "", Limit{}, fmt.Errorf("無効なホスト制限の指定です。\"<ドメイン> interval=<間隔> burst=<数> max-in-flight=<数>\" の形式で指定してください (指定値: %q)", rule)
+	}
+
+	domain := strings.ToLower(strings.TrimSuffix(fields[0], "."))
+	var limit Limit
+	for _, field := range fields[1:] {
+		key, value, found := strings.Cut(field, "=")
+		if !found {
+			return "", Limit{}, fmt.Errorf("無効なホスト制限の項目です (ドメイン: %s, 項目: %q)", domain, field)
+		}
+
+		var err error
+		switch key {
+		case "interval":
+			limit.Interval, err = time.ParseDuration(value)
+		case "burst":
+			limit.Burst, err = strconv.Atoi(value)
+		case "max-in-flight":
+			limit.MaxInFlight, err = strconv.Atoi(value)
+		default:
+			return "", Limit{}, fmt.Errorf("不明なホスト制限の項目です (ドメイン: %s, 項目: %s)。interval, burst, max-in-flight のいずれかを指定してください", domain, key)
+		}
+		if err != nil {
+			return "", Limit{}, fmt.Errorf("ホスト制限の値の解析エラー (ドメイン: %s, 項目: %s): %w", domain, key, err)
+		}
+	}
+	if err := limit.Validate(); err != nil {
+		return "", Limit{}, fmt.Errorf("ドメイン %s: %w", domain, err)
+	}
+	return domain, limit, nil
+}
+
+// LoadOverrides は、1行に1件の上書き設定 (ParseRule の形式) を記述したファイルを読み込みます。
+// 空行と '#' で始まる行は無視し、同じドメインが複数回指定された場合は後の行を優先します。
+func LoadOverrides(path string) (map[string]Limit, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ホスト制限ファイルのオープンエラー (%s): %w", path, err)
+	}
+	defer f.Close()
+
+	overrides, err := ReadOverrides(f)
+	if err != nil {
+		return nil, fmt.Errorf("ホスト制限ファイルの読み込みエラー (%s): %w", path, err)
+	}
+	return overrides, nil
+}
+
+// ReadOverrides は、1行に1件の上書き設定 (ParseRule の形式) を読み込みます。
+func ReadOverrides(r io.Reader) (map[string]Limit, error) {
+	overrides := make(map[string]Limit)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		domain, limit, err := ParseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("%d行目: %w", lineNo, err)
+		}
+		overrides[domain] = limit
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// --- ホストごとの制限 ---
+
+// hostState は、1ホスト分のトークンバケットと同時リクエスト数のスロットです。
+type hostState struct {
+	limiter *rate.Limiter
+	slots   chan struct{} // バッファ付きチャネルをセマフォとして使用する
+}
+
+// Limiter は、ホストごとにトークンバケットによるレート制限と同時リクエスト数の上限を適用します。
+// 複数のゴルーチンから安全に使用できます。
+type Limiter struct {
+	config Config
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// NewLimiter は、設定に基づいて Limiter を初期化します。
+func NewLimiter(config Config) *Limiter {
+	return &Limiter{
+		config: config,
+		hosts:  make(map[string]*hostState),
+	}
+}
+
+// state は、ホストの状態を返します。初回はホストに適用する制限から状態を作成します。
+func (l *Limiter) state(host string) *hostState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if s, ok := l.hosts[host]; ok {
+		return s
+	}
+	limit := l.config.limitFor(host)
+	s := &hostState{
+		limiter: rate.NewLimiter(rate.Every(limit.Interval), limit.Burst),
+		slots:   make(chan struct{}, limit.MaxInFlight),
+	}
+	l.hosts[host] = s
+	return s
+}
+
+// SetCrawlDelay は、robots.txt の Crawl-delay をホストのレート制限に反映します。
+// 設定済みの間隔より長い場合のみ、リクエストの間隔を delay に延ばし、バーストを1にします。
+func (l *Limiter) SetCrawlDelay(host string, delay time.Duration) {
+	s := l.state(strings.ToLower(host))
+	if rate.Every(delay) < s.limiter.Limit() {
+		s.limiter.SetLimit(rate.Every(delay))
+		s.limiter.SetBurst(1)
+	}
+}
+
+// Acquire は、URLのホストの同時リクエスト数のスロットとトークンを取得するまで待機します。
+// 戻り値の release は、リクエストの完了後に必ず呼び出してスロットを解放してください。
+// 待機中にコンテキストがキャンセルされた場合はエラーを返します。
+func (l *Limiter) Acquire(ctx context.Context, rawURL string) (release func(), err error) {
+	s := l.state(hostOf(rawURL))
+
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release = func() { <-s.slots }
+
+	if err := s.limiter.Wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// hostOf は、制限の単位となるURLのホスト名を返します。解析できない場合はURLをそのまま返します。
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return rawURL
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,96 @@
+package hostlimit
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		rule       string
+		wantDomain string
+		wantLimit  Limit
+		wantErr    string
+	}{
+		{
+			rule:       "news.example.com interval=2s burst=3 max-in-flight=1",
+			wantDomain: "news.example.com",
+			wantLimit:  Limit{Interval: 2 * time.Second, Burst: 3, MaxInFlight: 1},
+		},
+		{
+			rule:       "  Example.COM.   interval=500ms ",
+			wantDomain: "example.com",
+			wantLimit:  Limit{Interval: 500 * time.Millisecond},
+		},
+		{rule: "", wantErr: "無効なホスト制限の指定です"},
+		{rule: "example.com", wantErr: "無効なホスト制限の指定です"},
+		{rule: "example.com interval", wantErr: "無効なホスト制限の項目です"},
+		{rule: "example.com delay=1s", wantErr: "不明なホスト制限の項目です"},
+		{rule: "example.com interval=fast", wantErr: "値の解析エラー"},
+		{rule: "example.com burst=1.5", wantErr: "値の解析エラー"},
+		{rule: "example.com max-in-flight=-1", wantErr: "0 以上の値"},
+		{rule: "example.com interval=-1s", wantErr: "0 以上の値"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			domain, limit, err := ParseRule(tt.rule)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseRule(%q) error = %v, want error containing %q", tt.rule, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRule(%q) error = %v", tt.rule, err)
+			}
+			if domain != tt.wantDomain || limit != tt.wantLimit {
+				t.Errorf("ParseRule(%q) = %q, %+v, want %q, %+v", tt.rule, domain, limit, tt.wantDomain, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestReadOverrides(t *testing.T) {
+	overrides, err := ReadOverrides(strings.NewReader("# overrides\n\nexample.com burst=2\nexample.com burst=4\nslow.test interval=5s\n"))
+	if err != nil {
+		t.Fatalf("ReadOverrides() error = %v", err)
+	}
+	if len(overrides) != 2 || overrides["example.com"].Burst != 4 || overrides["slow.test"].Interval != 5*time.Second {
+		t.Errorf("ReadOverrides() = %+v", overrides)
+	}
+
+	if _, err := ReadOverrides(strings.NewReader("example.com burst=2\nbroken\n")); err == nil || !strings.Contains(err.Error(), "2行目") {
+		t.Errorf("ReadOverrides() error = %v, want error for line 2", err)
+	}
+}
+
+func TestLimitFor(t *testing.T) {
+	config := Config{
+		Default: Limit{Interval: time.Second},
+		Overrides: map[string]Limit{
+			"example.com":      {MaxInFlight: 4},
+			"news.example.com": {Interval: 3 * time.Second},
+		},
+	}
+
+	tests := []struct {
+		host string
+		want Limit
+	}{
+		{"other.test", Limit{Interval: time.Second, Burst: DefaultBurst, MaxInFlight: DefaultMaxInFlight}},
+		{"example.com", Limit{Interval: time.Second, Burst: DefaultBurst, MaxInFlight: 4}},
+		{"www.example.com", Limit{Interval: time.Second, Burst: DefaultBurst, MaxInFlight: 4}},
+		{"a.news.example.com", Limit{Interval: 3 * time.Second, Burst: DefaultBurst, MaxInFlight: DefaultMaxInFlight}},
+		{"notexample.com", Limit{Interval: time.Second, Burst: DefaultBurst, MaxInFlight: DefaultMaxInFlight}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := config.limitFor(tt.host); got != tt.want {
+				t.Errorf("limitFor(%q) = %+v, want %+v", tt.host, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package hostlimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/shouni/go-web-exact/v2/pkg/scraper"
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// Extractor はコンテンツ抽出ロジックの抽象化です (extract.Extractor が実装します)。
+type Extractor interface {
+	FetchAndExtractText(ctx context.Context, url string) (string, bool, error)
+}
+
+// Scraper は、scraper.Scraper インターフェースを実装する並列処理構造体です。
+// scraper.ParallelScraper と異なり、全体の同時実行数に加えてホストごとのレート制限と
+// 同時リクエスト数の上限を適用します。ホストのスロットとトークンを取得してから全体のスロットを確保するため、
+// 制限の厳しいホストの待機が全体のスロットを占有せず、他のホストのURLの抽出は待たされません。
+// 全体のスロットの待機中にトークンを保持できるのはホストごとに同時リクエスト数の上限までのため、
+// スロットが空いた時点でまとめて送信されるリクエストもその件数までに抑えられます。
+type Scraper struct {
+	extractor      Extractor
+	maxConcurrency int      // 全体の最大並列数 (セマフォで使用)
+	limiter        *Limiter // ホストごとの制限
+}
+
+var _ scraper.Scraper = (*Scraper)(nil)
+
+// NewScraper は Scraper を初期化します。
+// maxConcurrency が0以下の場合は scraper.DefaultMaxConcurrency を使用します。
+func NewScraper(extractor Extractor, maxConcurrency int, limiter *Limiter) *Scraper {
+	if maxConcurrency <= 0 {
+		maxConcurrency = scraper.DefaultMaxConcurrency
+	}
+	return &Scraper{
+		extractor:      extractor,
+		maxConcurrency: maxConcurrency,
+		limiter:        limiter,
+	}
+}
+
+// ScrapeInParallel は scraper.Scraper インターフェースのメソッドを実装します。
+// 結果の順序は完了順です (ReliableScraper が入力URLの順序に並べ替えます)。
+func (s *Scraper) ScrapeInParallel(ctx context.Context, urls []string) []types.URLResult {
+	finalResults := make([]types.URLResult, 0, len(urls))
+	for res := range s.ScrapeStream(ctx, urls) {
+		finalResults = append(finalResults, res)
+	}
+	return finalResults
+}
+
+// ScrapeStream は、URLリストの並列抽出を開始し、抽出が完了したURLから順に結果を送信するチャネルを返します。
+// チャネルはすべてのURLの結果を送信した後に閉じられます。
+// チャネルは入力URL数のバッファを持つため、受信が遅れても抽出は止まりません。
+func (s *Scraper) ScrapeStream(ctx context.Context, urls []string) <-chan types.URLResult {
+	var wg sync.WaitGroup
+	resultsChan := make(chan types.URLResult, len(urls))
+
+	// バッファ付きチャネルをセマフォとして使用し、全体の同時実行数を制限する
+	semaphore := make(chan struct{}, s.maxConcurrency)
+
+	for _, url := range urls {
+		wg.Add(1)
+
+		go func(u string) {
+			defer wg.Done()
+			resultsChan <- s.scrape(ctx, semaphore, u)
+		}(url)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+	return resultsChan
+}
+
+// scrape は、ホストのスロットとトークンを取得し、全体のスロットを確保してから、1件のURLを抽出します。
+func (s *Scraper) scrape(ctx context.Context, semaphore chan struct{}, u string) types.URLResult {
+	// 1. ホストのスロットとトークンの取得 (ホストの制限に達している場合はここで待機)
+	// 全体のスロットより先に取得し、待機中のホストが全体のスロットを占有しないようにする
+	release, err := s.limiter.Acquire(ctx, u)
+	if err != nil {
+		return types.URLResult{
+			URL:   u,
+			Error: fmt.Errorf("ホストごとのレートリミット待機中にキャンセル: %w", err),
+		}
+	}
+	defer release()
+
+	// 2. 全体のスロットの確保
+	select {
+	case semaphore <- struct{}{}:
+	case <-ctx.Done():
+		return types.URLResult{
+			URL:   u,
+			Error: fmt.Errorf("並列実行スロットの待機中にキャンセル: %w", ctx.Err()),
+		}
+	}
+	defer func() { <-semaphore }()
+
+	content, hasBodyFound, err := s.extractor.FetchAndExtractText(ctx, u)
+
+	var extractErr error
+	if err != nil {
+		extractErr = fmt.Errorf("コンテンツの抽出に失敗しました: %w", err)
+	} else if !hasBodyFound {
+		// 本文が見つからなかった場合を抽出失敗と判断
+		extractErr = fmt.Errorf("URL %s から有効な本文を抽出できませんでした", u)
+	}
+
+	return types.URLResult{
+		URL:     u,
+		Content: content,
+		Error:   extractErr,
+	}
+}
+
+// limitedExtractor は、抽出の前にホストごとの制限を適用する Extractor です。
+type limitedExtractor struct {
+	extractor Extractor
+	limiter   *Limiter
+}
+
+// LimitExtractor は、抽出の前にホストごとの制限を適用する Extractor を返します。
+// ReliableScraper のリトライによる単体抽出にも同じ制限を適用するために使用します。
+func LimitExtractor(extractor Extractor, limiter *Limiter) Extractor {
+	return &limitedExtractor{extractor: extractor, limiter: limiter}
+}
+
+func (e *limitedExtractor) FetchAndExtractText(ctx context.Context, url string) (string, bool, error) {
+	release, err := e.limiter.Acquire(ctx, url)
+	if err != nil {
+		return "", false, fmt.Errorf("ホストごとのレートリミット待機中にキャンセル: %w", err)
+	}
+	defer release()
+	return e.extractor.FetchAndExtractText(ctx, url)
+}
@@ -0,0 +1,56 @@
+package hostlimit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeExtractor は、すべてのURLで本文を即座に返す Extractor です。
+type fakeExtractor struct{}
+
+func (fakeExtractor) FetchAndExtractText(ctx context.Context, url string) (string, bool, error) {
+	return "本文: " + url, true, nil
+}
+
+func TestScraperDoesNotBlockOtherHosts(t *testing.T) {
+	const slowInterval = 2 * time.Second
+	limiter := NewLimiter(Config{
+		Default: Limit{Interval: time.Millisecond, Burst: 10, MaxInFlight: 4},
+		Overrides: map[string]Limit{
+			"slow.example": {Interval: slowInterval, Burst: 1, MaxInFlight: 1},
+		},
+	})
+	// 全体の並列数より多い遅いホストのURLを先に並べる
+	urls := []string{
+		"https://slow.example/1", "https://slow.example/2", "https://slow.example/3", "https://slow.example/4",
+		"https://fast.example/1", "https://fast.example/2", "https://fast.example/3", "https://fast.example/4",
+	}
+	s := NewScraper(fakeExtractor{}, 2, limiter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	fastDone := 0
+	for res := range s.ScrapeStream(ctx, urls) {
+		if !strings.HasPrefix(res.URL, "https://fast.example/") {
+			continue
+		}
+		if res.Error != nil {
+			t.Fatalf("%s: unexpected error: %v", res.URL, res.Error)
+		}
+		if elapsed := time.Since(start); elapsed >= slowInterval/2 {
+			t.Fatalf("%s finished after %s, waited on the slow host", res.URL, elapsed)
+		}
+		fastDone++
+		if fastDone == 4 {
+			// 残りの遅いホストのURLは待たずに打ち切る
+			cancel()
+		}
+	}
+	if fastDone != 4 {
+		t.Fatalf("fast host results = %d, want 4", fastDone)
+	}
+}
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/output"
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+)
+
+// Status は、ジョブの状態です。
+type Status string
+
+const (
+	StatusQueued    Status = "queued"    // 実行待ち
+	StatusRunning   Status = "running"   // 実行中
+	StatusSucceeded Status = "succeeded" // 完了 (個々の記事の失敗は Result に記録される)
+	StatusFailed    Status = "failed"    // ワークフロー全体のエラーにより失敗
+)
+
+// ErrNotFound は、指定IDのジョブが存在しない場合のエラーです。
+var ErrNotFound = errors.New("ジョブが見つかりません")
+
+// Job は、非同期に実行されるスクレイピングジョブです。
+type Job struct {
+	ID         string              `json:"id"`
+	Status     Status              `json:"status"`
+	Config     runner.RunnerConfig `json:"config"`
+	Progress   runner.Progress     `json:"progress"`
+	Result     *output.Result      `json:"result,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+	StartedAt  time.Time           `json:"started_at,omitzero"`
+	FinishedAt time.Time           `json:"finished_at,omitzero"`
+}
+
+// Finished は、ジョブが終了状態 (完了または失敗) かどうかを判定します。
+func (j *Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// newID は、ジョブIDとして使用するランダムな16進文字列を生成します。
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
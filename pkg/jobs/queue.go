@@ -0,0 +1,290 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/output"
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+)
+
+const (
+	DefaultWorkers   = 1              // 同時に実行するジョブ数のデフォルト値
+	DefaultMaxQueued = 100            // 実行待ちにできるジョブ数のデフォルト値
+	DefaultTTL       = 24 * time.Hour // 終了したジョブを保持する期間のデフォルト値
+
+	// progressSaveInterval は、実行中のジョブの進捗をジョブストアに保存する最小間隔です。
+	// 進捗は記事ごとに通知されるため、保存 (fsync) の回数を抑えるために間引きます。
+	progressSaveInterval = time.Second
+	// pruneInterval は、終了したジョブの削除を行う最大間隔です。
+	pruneInterval = 10 * time.Minute
+)
+
+// ErrQueueFull は、実行待ちのジョブ数が上限に達している場合のエラーです。
+var ErrQueueFull = errors.New("実行待ちのジョブ数が上限に達しています")
+
+// Queue は、スクレイピングジョブを永続化し、ワーカーで順次実行するジョブキューです。
+// 停止時に実行中だったジョブは実行待ちに戻され、次回の起動時に最初から再実行されます。
+type Queue struct {
+	store     *Store
+	runner    *runner.Runner
+	workers   int
+	maxQueued int
+
+	// TTL が0より大きい場合、終了してから TTL を経過したジョブを起動時と定期的に削除します。
+	TTL time.Duration
+
+	mu      sync.Mutex
+	pending []string      // 実行待ちのジョブID (投入順)
+	notify  chan struct{} // 実行待ちのジョブが追加されたことをワーカーに通知する
+}
+
+// NewQueue は、ジョブストアと Runner を注入して Queue を初期化します。
+func NewQueue(store *Store, r *runner.Runner, workers, maxQueued int) *Queue {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if maxQueued <= 0 {
+		maxQueued = DefaultMaxQueued
+	}
+	return &Queue{
+		store:     store,
+		runner:    r,
+		workers:   workers,
+		maxQueued: maxQueued,
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+// Enqueue は、RunnerConfig をジョブとして保存し、実行待ちに追加します。
+func (q *Queue) Enqueue(config runner.RunnerConfig) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) >= q.maxQueued {
+		return nil, fmt.Errorf("%w (上限: %d)", ErrQueueFull, q.maxQueued)
+	}
+
+	job := &Job{
+		ID:        newID(),
+		Status:    StatusQueued,
+		Config:    config,
+		CreatedAt: time.Now(),
+	}
+	if err := q.store.Put(job); err != nil {
+		return nil, err
+	}
+
+	q.pending = append(q.pending, job.ID)
+	q.signal()
+	return job, nil
+}
+
+// Get は、指定IDのジョブの現在の状態を返します。
+func (q *Queue) Get(id string) (*Job, error) {
+	return q.store.Get(id)
+}
+
+// Run は、ジョブストアに残っている未完了のジョブを実行待ちに戻し、ctx がキャンセルされるまでワーカーでジョブを実行します。
+// キャンセル後は実行中のジョブの中断を待ってから戻ります。
+func (q *Queue) Run(ctx context.Context) error {
+	if err := q.recover(); err != nil {
+		return err
+	}
+
+	slog.Info("ジョブキューを開始しました", slog.Int("workers", q.workers), slog.Int("queued", len(q.pending)))
+
+	var wg sync.WaitGroup
+	if q.TTL > 0 {
+		q.prune()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.pruneLoop(ctx)
+		}()
+	}
+	for range q.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	slog.Info("ジョブキューを停止しました", slog.Int("queued", len(q.pending)))
+	return nil
+}
+
+// recover は、前回の停止時に実行待ち・実行中だったジョブを投入順に実行待ちに戻します。
+func (q *Queue) recover() error {
+	jobs, err := q.store.List()
+	if err != nil {
+		return err
+	}
+
+	var unfinished []*Job
+	for _, job := range jobs {
+		if !job.Finished() {
+			unfinished = append(unfinished, job)
+		}
+	}
+	slices.SortFunc(unfinished, func(a, b *Job) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range unfinished {
+		if job.Status == StatusRunning {
+			resetJob(job)
+			if err := q.store.Put(job); err != nil {
+				return err
+			}
+		}
+		q.pending = append(q.pending, job.ID)
+	}
+	if len(q.pending) > 0 {
+		q.signal()
+	}
+	return nil
+}
+
+// work は、ctx がキャンセルされるまで実行待ちのジョブを取り出して実行します。
+func (q *Queue) work(ctx context.Context) {
+	for {
+		id, ok := q.next(ctx)
+		if !ok {
+			return
+		}
+		job, err := q.store.Get(id)
+		if err != nil {
+			slog.Error("ジョブの読み込みに失敗しました", slog.String("job_id", id), slog.Any("error", err))
+			continue
+		}
+		q.runJob(ctx, job)
+	}
+}
+
+// next は、実行待ちの先頭のジョブIDを取り出します。実行待ちがない場合は追加されるまで待機し、
+// ctx がキャンセルされた場合は false を返します。
+func (q *Queue) next(ctx context.Context) (string, bool) {
+	for {
+		if ctx.Err() != nil {
+			return "", false
+		}
+
+		q.mu.Lock()
+		if len(q.pending) > 0 {
+			id := q.pending[0]
+			q.pending = q.pending[1:]
+			if len(q.pending) > 0 {
+				// 待機中の他のワーカーにも残りのジョブを知らせる
+				q.signal()
+			}
+			q.mu.Unlock()
+			return id, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return "", false
+		case <-q.notify:
+		}
+	}
+}
+
+// runJob は、ジョブを実行し、進捗と結果をジョブストアに保存します。
+func (q *Queue) runJob(ctx context.Context, job *Job) {
+	slog.Info("ジョブを開始します", slog.String("job_id", job.ID), slog.Int("feed_count", len(job.Config.FeedURLs)), slog.Int("sitemap_count", len(job.Config.SitemapURLs)))
+
+	job.Status = StatusRunning
+	job.StartedAt = time.Now()
+	q.save(job)
+
+	// 進捗の保存は progressSaveInterval ごとに間引く (最新の進捗は終了時の保存で記録される)
+	var lastSaved time.Time
+	progressCtx := runner.WithProgress(ctx, func(progress runner.Progress) {
+		job.Progress = progress
+		if time.Since(lastSaved) < progressSaveInterval {
+			return
+		}
+		lastSaved = time.Now()
+		q.save(job)
+	})
+	runnerResult, err := q.runner.ScrapeAndRun(progressCtx, job.Config)
+
+	if ctx.Err() != nil {
+		// 停止による中断の場合は結果を保存せず、次回の起動時に再実行する
+		slog.Warn("停止のためジョブを中断しました。次回の起動時に再実行します。", slog.String("job_id", job.ID))
+		resetJob(job)
+		q.save(job)
+		return
+	}
+
+	job.FinishedAt = time.Now()
+	if err != nil {
+		slog.Error("ジョブが失敗しました", slog.String("job_id", job.ID), slog.Any("error", err))
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		slog.Info("ジョブが完了しました", slog.String("job_id", job.ID), slog.Int("done", job.Progress.Done), slog.Int("failed", job.Progress.Failed))
+		job.Status = StatusSucceeded
+		job.Result = output.NewResult(runnerResult)
+	}
+	q.save(job)
+}
+
+// save は、ジョブをジョブストアに保存します。保存の失敗はログに記録し、ジョブの実行は継続します。
+func (q *Queue) save(job *Job) {
+	if err := q.store.Put(job); err != nil {
+		slog.Error("ジョブの保存に失敗しました", slog.String("job_id", job.ID), slog.Any("error", err))
+	}
+}
+
+// pruneLoop は、ctx がキャンセルされるまで、終了したジョブの削除を定期的に行います。
+func (q *Queue) pruneLoop(ctx context.Context) {
+	ticker := time.NewTicker(min(q.TTL, pruneInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.prune()
+		}
+	}
+}
+
+// prune は、終了してから TTL を経過したジョブをジョブストアから削除します。削除の失敗はログに記録します。
+func (q *Queue) prune() {
+	deleted, err := q.store.DeleteFinishedBefore(time.Now().Add(-q.TTL))
+	if err != nil {
+		slog.Error("終了したジョブの削除に失敗しました", slog.Any("error", err))
+		return
+	}
+	if deleted > 0 {
+		slog.Info("保持期間を過ぎたジョブを削除しました", slog.Int("deleted", deleted), slog.Duration("ttl", q.TTL))
+	}
+}
+
+// signal は、待機中のワーカーに実行待ちのジョブがあることを通知します。既に通知済みの場合は何もしません。
+func (q *Queue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// resetJob は、中断されたジョブを実行前の状態に戻します。
+func resetJob(job *Job) {
+	job.Status = StatusQueued
+	job.Progress = runner.Progress{}
+	job.StartedAt = time.Time{}
+}
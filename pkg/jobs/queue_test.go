@@ -0,0 +1,101 @@
+package jobs
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+)
+
+// openTestStore は、テスト用の一時ディレクトリにジョブストアを開きます。
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestQueueRecover(t *testing.T) {
+	store := openTestStore(t)
+	base := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	saved := []*Job{
+		{ID: "running-2", Status: StatusRunning, CreatedAt: base.Add(2 * time.Minute), StartedAt: base.Add(3 * time.Minute), Progress: runner.Progress{Total: 3, Done: 1, Pending: 2}},
+		{ID: "queued-0", Status: StatusQueued, CreatedAt: base},
+		{ID: "succeeded", Status: StatusSucceeded, CreatedAt: base.Add(-time.Hour), FinishedAt: base},
+		{ID: "running-1", Status: StatusRunning, CreatedAt: base.Add(time.Minute), StartedAt: base.Add(time.Minute)},
+		{ID: "failed", Status: StatusFailed, CreatedAt: base.Add(-time.Minute), FinishedAt: base},
+	}
+	for _, job := range saved {
+		if err := store.Put(job); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	q := NewQueue(store, nil, 1, 10)
+	if err := q.recover(); err != nil {
+		t.Fatalf("recover() error = %v", err)
+	}
+
+	// 未完了のジョブが投入順 (CreatedAt の順) に実行待ちに戻る
+	if want := []string{"queued-0", "running-1", "running-2"}; !slices.Equal(q.pending, want) {
+		t.Errorf("pending = %v, want %v", q.pending, want)
+	}
+
+	for _, id := range []string{"running-1", "running-2"} {
+		job, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", id, err)
+		}
+		if job.Status != StatusQueued || !job.StartedAt.IsZero() || job.Progress != (runner.Progress{}) {
+			t.Errorf("%s = {Status: %s, StartedAt: %s, Progress: %+v}, want reset to queued", id, job.Status, job.StartedAt, job.Progress)
+		}
+	}
+	if job, _ := store.Get("succeeded"); job.Status != StatusSucceeded {
+		t.Errorf("succeeded job status = %s, want %s", job.Status, StatusSucceeded)
+	}
+}
+
+func TestQueueEnqueueFull(t *testing.T) {
+	q := NewQueue(openTestStore(t), nil, 1, 2)
+	for range 2 {
+		if _, err := q.Enqueue(runner.RunnerConfig{FeedURLs: []string{"https://example.com/feed.xml"}}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	if _, err := q.Enqueue(runner.RunnerConfig{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Enqueue() error = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestStoreDeleteFinishedBefore(t *testing.T) {
+	store := openTestStore(t)
+	now := time.Now()
+	for _, job := range []*Job{
+		{ID: "old", Status: StatusSucceeded, FinishedAt: now.Add(-2 * time.Hour)},
+		{ID: "recent", Status: StatusFailed, FinishedAt: now.Add(-time.Minute)},
+		{ID: "queued", Status: StatusQueued},
+	} {
+		if err := store.Put(job); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	deleted, err := store.DeleteFinishedBefore(now.Add(-time.Hour))
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteFinishedBefore() = %d, %v, want 1, nil", deleted, err)
+	}
+	if _, err := store.Get("old"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(old) error = %v, want ErrNotFound", err)
+	}
+	for _, id := range []string{"recent", "queued"} {
+		if _, err := store.Get(id); err != nil {
+			t.Errorf("Get(%s) error = %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	jobsBucket = "jobs" // ジョブIDをキーとするジョブ
+
+	openTimeout = 5 * time.Second // 他プロセスがロック中の場合に待機する最大時間
+)
+
+// Store は、ジョブを永続化する bbolt ベースのストアです。
+type Store struct {
+	db *bolt.DB
+}
+
+// Open は、指定パスのジョブストアを開きます。ファイルが存在しない場合は作成します。
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("ジョブストアのオープンエラー (%s): %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(jobsBucket))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ジョブストアの初期化エラー (%s): %w", path, err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close は、ジョブストアを閉じます。
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Put は、ジョブを保存します。同じIDのジョブが既に保存されている場合は上書きします。
+func (s *Store) Put(job *Job) error {
+	value, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("JSONエンコードエラー (ジョブ: %s): %w", job.ID, err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(jobsBucket)).Put([]byte(job.ID), value)
+	})
+	if err != nil {
+		return fmt.Errorf("ジョブストアの書き込みエラー (ジョブ: %s): %w", job.ID, err)
+	}
+	return nil
+}
+
+// Get は、指定IDのジョブを返します。存在しない場合は ErrNotFound を返します。
+func (s *Store) Get(id string) (*Job, error) {
+	var job *Job
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket([]byte(jobsBucket)).Get([]byte(id))
+		if value == nil {
+			return nil
+		}
+		job = &Job{}
+		return json.Unmarshal(value, job)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ジョブストアの読み込みエラー (ジョブ: %s): %w", id, err)
+	}
+	if job == nil {
+		return nil, fmt.Errorf("%w (ID: %s)", ErrNotFound, id)
+	}
+	return job, nil
+}
+
+// List は、保存されているすべてのジョブを返します。順序は不定です。
+func (s *Store) List() ([]*Job, error) {
+	var jobs []*Job
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(jobsBucket)).ForEach(func(k, v []byte) error {
+			job := &Job{}
+			if err := json.Unmarshal(v, job); err != nil {
+				return fmt.Errorf("JSONデコードエラー (ジョブ: %s): %w", k, err)
+			}
+			jobs = append(jobs, job)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ジョブストアの読み込みエラー: %w", err)
+	}
+	return jobs, nil
+}
+
+// DeleteFinishedBefore は、終了日時が before より前の終了済みのジョブを削除し、削除した件数を返します。
+func (s *Store) DeleteFinishedBefore(before time.Time) (int, error) {
+	deleted := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(jobsBucket))
+
+		// 走査中に削除するとカーソルが要素を読み飛ばすことがあるため、削除するキーを集めてから削除する
+		var keys [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			job := &Job{}
+			if err := json.Unmarshal(v, job); err != nil {
+				return fmt.Errorf("JSONデコードエラー (ジョブ: %s): %w", k, err)
+			}
+			if job.Finished() && job.FinishedAt.Before(before) {
+				keys = append(keys, bytes.Clone(k))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		deleted = len(keys)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ジョブストアの書き込みエラー: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shouni/go-web-exact/v2/pkg/extract"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+const namespace = "webtextpipe"
+
+// Metrics は、抽出パイプラインの Prometheus メトリクスを保持します。
+// runner.MetricsRecorder を実装し、Runner と ReliableScraper に注入して使用します。
+type Metrics struct {
+	registry *prometheus.Registry
+
+	articlesAttempted *prometheus.CounterVec
+	articlesSucceeded *prometheus.CounterVec
+	articlesFailed    *prometheus.CounterVec
+	retrySuccesses    *prometheus.CounterVec
+	phaseDuration     *prometheus.HistogramVec
+	feedFetchErrors   *prometheus.CounterVec
+	fetchDuration     *prometheus.HistogramVec
+	fetchedBytes      *prometheus.CounterVec
+}
+
+// New は、メトリクスを専用のレジストリに登録して Metrics を初期化します。
+// withRuntime が true の場合は、Go ランタイムとプロセスのメトリクスも登録します (常駐プロセス向け)。
+func New(withRuntime bool) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		articlesAttempted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "articles_attempted_total",
+			Help:      "記事の抽出を試行した回数",
+		}, []string{"host", "phase"}),
+		articlesSucceeded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "articles_succeeded_total",
+			Help:      "記事の抽出に成功した回数",
+		}, []string{"host", "phase"}),
+		articlesFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "articles_failed_total",
+			Help:      "記事の抽出に失敗した回数",
+		}, []string{"host", "phase"}),
+		retrySuccesses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retry_successes_total",
+			Help:      "ワークフロー層のリトライで抽出に成功した記事数 (何回目のリトライで成功したか)",
+		}, []string{"attempt"}),
+		phaseDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "extraction_phase_duration_seconds",
+			Help:      "抽出フェーズ (並列抽出・リトライ) 全体の所要時間",
+			Buckets:   []float64{1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+		}, []string{"phase"}),
+		feedFetchErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "feed_fetch_errors_total",
+			Help:      "フィード・サイトマップの取得・解析に失敗した回数",
+		}, []string{"kind", "host"}),
+		fetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "fetch_duration_seconds",
+			Help:      "HTTPによるページ・フィードの取得1回あたりの所要時間",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"host"}),
+		fetchedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "fetched_bytes_total",
+			Help:      "HTTPで取得したレスポンスボディの合計バイト数",
+		}, []string{"host"}),
+	}
+
+	m.registry.MustRegister(
+		m.articlesAttempted,
+		m.articlesSucceeded,
+		m.articlesFailed,
+		m.retrySuccesses,
+		m.phaseDuration,
+		m.feedFetchErrors,
+		m.fetchDuration,
+		m.fetchedBytes,
+	)
+	if withRuntime {
+		m.registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
+	return m
+}
+
+// Handler は、/metrics で公開するための http.Handler を返します。
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Push は、メトリクスを Pushgateway に送信します。一回限りの実行 (scraper / batch) の終了時に使用します。
+func (m *Metrics) Push(ctx context.Context, gatewayURL, job string) error {
+	return push.New(gatewayURL, job).Gatherer(m.registry).PushContext(ctx)
+}
+
+// --- runner.MetricsRecorder の実装 ---
+
+func (m *Metrics) ArticleAttempted(rawURL, phase string) {
+	m.articlesAttempted.WithLabelValues(hostOf(rawURL), phase).Inc()
+}
+
+func (m *Metrics) ArticleSucceeded(rawURL, phase string) {
+	m.articlesSucceeded.WithLabelValues(hostOf(rawURL), phase).Inc()
+}
+
+func (m *Metrics) ArticleFailed(rawURL, phase string) {
+	m.articlesFailed.WithLabelValues(hostOf(rawURL), phase).Inc()
+}
+
+func (m *Metrics) RetrySucceeded(rawURL string, attempt int) {
+	m.retrySuccesses.WithLabelValues(strconv.Itoa(attempt)).Inc()
+}
+
+func (m *Metrics) ObservePhaseDuration(phase string, d time.Duration) {
+	m.phaseDuration.WithLabelValues(phase).Observe(d.Seconds())
+}
+
+func (m *Metrics) FeedFetchFailed(kind, feedURL string) {
+	m.feedFetchErrors.WithLabelValues(kind, hostOf(feedURL)).Inc()
+}
+
+// --- Fetcher の計測 ---
+
+// instrumentedFetcher は、extract.Fetcher による取得の所要時間と取得バイト数を記録します。
+type instrumentedFetcher struct {
+	fetcher extract.Fetcher
+	metrics *Metrics
+}
+
+// InstrumentFetcher は、取得の所要時間と取得バイト数を記録する extract.Fetcher を返します。
+// m が nil の場合は fetcher をそのまま返します。
+func InstrumentFetcher(fetcher extract.Fetcher, m *Metrics) extract.Fetcher {
+	if m == nil {
+		return fetcher
+	}
+	return &instrumentedFetcher{fetcher: fetcher, metrics: m}
+}
+
+func (f *instrumentedFetcher) FetchBytes(ctx context.Context, rawURL string) ([]byte, error) {
+	host := hostOf(rawURL)
+	startedAt := time.Now()
+	body, err := f.fetcher.FetchBytes(ctx, rawURL)
+	f.metrics.fetchDuration.WithLabelValues(host).Observe(time.Since(startedAt).Seconds())
+	f.metrics.fetchedBytes.WithLabelValues(host).Add(float64(len(body)))
+	return body, err
+}
+
+// hostOf は、メトリクスのラベルに使用するURLのホスト名を返します。解析できない場合は "unknown" を返します。
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return "unknown"
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,191 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/forPelevin/gomoji"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Options は、抽出後のテキストに適用する正規化の設定です。
+type Options struct {
+	NFKC               bool // 全角英数字・半角カナなどを NFKC で統一する (日本語の表記を損なう文字は除外)
+	CollapseWhitespace bool // ゼロ幅文字の除去、空白の連続の圧縮、連続する空行の圧縮を行う
+	StripEmoji         bool // 絵文字として表示される文字 (既定で絵文字表示の文字と異体字セレクタ U+FE0F 付きの文字) を除去する
+}
+
+// Enabled は、いずれかの正規化が有効かどうかを返します。
+func (o Options) Enabled() bool {
+	return o.NFKC || o.CollapseWhitespace || o.StripEmoji
+}
+
+// nfkcExceptions は、NFKC を適用すると日本語の表記や意味を損なうため、そのまま残す文字です。
+var nfkcExceptions = []*unicode.RangeTable{
+	{R16: []unicode.Range16{
+		{Lo: 0x2025, Hi: 0x2026, Stride: 1}, // ‥ … (NFKC では "." の連続になる)
+		{Lo: 0x2460, Hi: 0x24FF, Stride: 1}, // ① などの囲み英数字 (NFKC では数字のみになる)
+		{Lo: 0x309B, Hi: 0x309C, Stride: 1}, // ゛ ゜ (NFKC では空白と結合文字になる)
+		{Lo: 0xFF5E, Hi: 0xFF5E, Stride: 1}, // ～ (NFKC では ASCII のチルダになる)
+	}},
+}
+
+// zeroWidthReplacer は、表示されないが単語の区切りやトークナイズを妨げる文字を除去します。
+// 絵文字の結合に使用されるゼロ幅接合子 (U+200D) は残します。
+var zeroWidthReplacer = strings.NewReplacer(
+	"\u200b", "", // ゼロ幅スペース
+	"\u200c", "", // ゼロ幅非接合子
+	"\u2060", "", // 単語結合子
+	"\ufeff", "", // ゼロ幅ノーブレークスペース (BOM)
+	"\u00ad", "", // ソフトハイフン
+)
+
+// blankLinesPattern は、2行以上連続する空行に一致します。
+var blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+
+// Normalizer は、抽出後のテキストを Options に従って正規化します。
+// nil の Normalizer はテキストをそのまま返します。
+type Normalizer struct {
+	opts Options
+}
+
+// New は Normalizer を初期化します。正規化がすべて無効の場合は nil を返します。
+func New(opts Options) *Normalizer {
+	if !opts.Enabled() {
+		return nil
+	}
+	return &Normalizer{opts: opts}
+}
+
+// Normalize は、テキストを正規化します。
+// 絵文字の除去、NFKC、空白の圧縮の順に適用します (絵文字の除去や NFKC で生じた空白を最後に圧縮するため)。
+func (n *Normalizer) Normalize(text string) string {
+	if n == nil {
+		return text
+	}
+	if n.opts.StripEmoji {
+		text = stripEmoji(text)
+	}
+	if n.opts.NFKC {
+		text = nfkc(text)
+	}
+	if n.opts.CollapseWhitespace {
+		text = collapseWhitespace(text)
+	}
+	return text
+}
+
+// nfkc は、nfkcExceptions の文字を除いて NFKC を適用します。
+func nfkc(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	start := 0
+	for i, r := range text {
+		if !unicode.In(r, nfkcExceptions...) {
+			continue
+		}
+		b.WriteString(norm.NFKC.String(text[start:i]))
+		b.WriteRune(r)
+		start = i + len(string(r))
+	}
+	b.WriteString(norm.NFKC.String(text[start:]))
+	return b.String()
+}
+
+// stripEmoji は、絵文字として表示される書記素クラスタを除去し、除去によって生じた空白の重複と行頭・行末の空白を取り除きます。
+// © や ™ など、既定でテキストとして表示される文字は、異体字セレクタ U+FE0F が付いていない限り残します。
+func stripEmoji(text string) string {
+	buf := make([]byte, 0, len(text))
+	removed := false // 直前の書記素クラスタを除去したかどうか
+	state := -1
+	for rest := text; rest != ""; {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		if isEmojiPresentation(cluster) {
+			removed = true
+			continue
+		}
+
+		if removed {
+			removed = false
+			switch {
+			case isInlineSpace(cluster) && (len(buf) == 0 || endsWithSpaceOrNewline(buf)):
+				// 除去した絵文字の前後の空白が重複する場合 (または行頭に残る場合) は後ろの空白を除く
+				continue
+			case strings.HasPrefix(cluster, "\n") || strings.HasPrefix(cluster, "\r"):
+				// 行末の絵文字を除去した後に残る空白を除く
+				buf = trimTrailingSpace(buf)
+			}
+		}
+		buf = append(buf, cluster...)
+	}
+	if removed {
+		buf = trimTrailingSpace(buf)
+	}
+	return string(buf)
+}
+
+// isEmojiPresentation は、書記素クラスタが絵文字として表示されるかを返します。
+// 異体字セレクタ U+FE0F 付きの文字、複数のコードポイントからなる絵文字 (ZWJ シーケンス・国旗・肌の色など)、
+// テキスト表示の異体字 (U+FE0F 付き) が定義されていない文字を絵文字として扱います。
+func isEmojiPresentation(cluster string) bool {
+	if strings.ContainsRune(cluster, '\uFE0E') {
+		// テキスト表示が指定されている
+		return false
+	}
+	base := strings.ReplaceAll(cluster, "\uFE0F", "")
+	if _, err := gomoji.GetInfo(base); err != nil {
+		if _, err := gomoji.GetInfo(cluster); err != nil {
+			return false
+		}
+	}
+	if base != cluster || utf8.RuneCountInString(base) > 1 {
+		return true
+	}
+	// U+FE0F 付きの表記が定義されている文字 (© ™ など) は、既定ではテキストとして表示される
+	_, err := gomoji.GetInfo(base + "\uFE0F")
+	return err != nil
+}
+
+// isInlineSpace は、書記素クラスタが行内の空白 (U+3000 を含む) かどうかを返します。
+func isInlineSpace(cluster string) bool {
+	return cluster == " " || cluster == "\t" || cluster == "\u3000"
+}
+
+// endsWithSpaceOrNewline は、buf が空白または改行で終わるかどうかを返します。
+func endsWithSpaceOrNewline(buf []byte) bool {
+	r, _ := utf8.DecodeLastRune(buf)
+	return r == ' ' || r == '\t' || r == '\u3000' || r == '\n' || r == '\r'
+}
+
+// trimTrailingSpace は、buf の末尾の行内の空白を取り除きます。
+func trimTrailingSpace(buf []byte) []byte {
+	for len(buf) > 0 {
+		r, size := utf8.DecodeLastRune(buf)
+		if r != ' ' && r != '\t' && r != '\u3000' {
+			break
+		}
+		buf = buf[:len(buf)-size]
+	}
+	return buf
+}
+
+// collapseWhitespace は、ゼロ幅文字を除去し、行内の空白 (U+3000 を含む) の連続を1つの半角スペースに、
+// 行頭・行末の空白を削除し、2行以上連続する空行を1行に圧縮します。
+func collapseWhitespace(text string) string {
+	text = zeroWidthReplacer.Replace(text)
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.FieldsFunc(line, unicode.IsSpace), " ")
+	}
+	text = strings.Join(lines, "\n")
+
+	text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
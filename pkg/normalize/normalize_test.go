@@ -0,0 +1,72 @@
+package normalize
+
+import "testing"
+
+func TestStripEmoji(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"絵文字表示の文字", "今日は晴れ😀です", "今日は晴れです"},
+		{"除去で重複した空白", "Hello 👋 world", "Hello world"},
+		{"行頭と行末の絵文字", "🎉 お知らせ\n更新しました 🙏\n", "お知らせ\n更新しました\n"},
+		{"末尾の絵文字", "ありがとう 🙇", "ありがとう"},
+		{"ZWJ シーケンスと肌の色", "家族👨‍👩‍👧と👍🏽", "家族と"},
+		{"国旗", "日本🇯🇵代表", "日本代表"},
+		{"テキスト表示の記号は残す", "© 2025 Example™ ↔ 1#", "© 2025 Example™ ↔ 1#"},
+		{"U+FE0F 付きの記号は除去する", "Example™️ と ©️ 表記", "Example と 表記"},
+		{"テキスト表示の指定は残す", "☺︎ です", "☺︎ です"},
+		{"漢字の異体字セレクタは残す", "葛\U000E0100飾区", "葛\U000E0100飾区"},
+		{"絵文字を含まない空白は変更しない", "a  b \n", "a  b \n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripEmoji(tt.text); got != tt.want {
+				t.Errorf("stripEmoji(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		text string
+		want string
+	}{
+		{"NFKC", Options{NFKC: true}, "ＡＢＣ１２３ ｱｲｳ", "ABC123 アイウ"},
+		{"NFKC の除外文字", Options{NFKC: true}, "①‥…～゛", "①‥…～゛"},
+		{"空白の圧縮", Options{CollapseWhitespace: true}, "  a \t b　c  \r\nd", "a b c\nd"},
+		{"ゼロ幅文字の除去", Options{CollapseWhitespace: true}, "ゼロ\u200b幅\ufeff文字\u00ad", "ゼロ幅文字"},
+		{"連続する空行の圧縮", Options{CollapseWhitespace: true}, "a\n\n\n\n\nb\n \n\n c", "a\n\nb\n\nc"},
+		{"全角スペースは NFKC の後に圧縮する", Options{NFKC: true, CollapseWhitespace: true}, "a　　b", "a b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.opts).Normalize(tt.text); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	n := New(Options{NFKC: true, CollapseWhitespace: true, StripEmoji: true})
+	got := n.Normalize("ＡＢＣ　１２３ 🚀\n\n\n\n次の行…①")
+	want := "ABC 123\n\n次の行…①"
+	if got != want {
+		t.Errorf("Normalize() = %q, want %q", got, want)
+	}
+
+	if New(Options{}) != nil {
+		t.Error("New(Options{}) != nil")
+	}
+	var nilNormalizer *Normalizer
+	if got := nilNormalizer.Normalize(" a "); got != " a " {
+		t.Errorf("nil Normalizer changed text: %q", got)
+	}
+}
@@ -0,0 +1,64 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// document は OPML ファイルのルート要素です。
+type document struct {
+	XMLName xml.Name  `xml:"opml"`
+	Body    []outline `xml:"body>outline"`
+}
+
+// outline は OPML の outline 要素です。カテゴリとして入れ子になる場合があります。
+type outline struct {
+	Text     string    `xml:"text,attr"`
+	Type     string    `xml:"type,attr"`
+	XMLURL   string    `xml:"xmlUrl,attr"`
+	Outlines []outline `xml:"outline"`
+}
+
+// LoadFeedURLs は、指定パスの OPML ファイルを読み込み、含まれるフィードURLを返します。
+func LoadFeedURLs(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("OPMLファイルのオープンエラー (%s): %w", path, err)
+	}
+	defer f.Close()
+
+	urls, err := ParseFeedURLs(f)
+	if err != nil {
+		return nil, fmt.Errorf("OPMLファイルの解析エラー (%s): %w", path, err)
+	}
+	return urls, nil
+}
+
+// ParseFeedURLs は、OPML ドキュメントを解析し、xmlUrl 属性を持つ outline のURLを出現順に返します。
+// カテゴリとして入れ子になった outline も再帰的に探索します。
+func ParseFeedURLs(r io.Reader) ([]string, error) {
+	var doc document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("OPMLのXMLデコードエラー: %w", err)
+	}
+
+	var urls []string
+	var walk func(outlines []outline)
+	walk = func(outlines []outline) {
+		for _, o := range outlines {
+			if u := strings.TrimSpace(o.XMLURL); u != "" {
+				urls = append(urls, u)
+			}
+			walk(o.Outlines)
+		}
+	}
+	walk(doc.Body)
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("OPMLにフィードURL (xmlUrl) が一つも含まれていません")
+	}
+	return urls, nil
+}
@@ -0,0 +1,74 @@
+package opml
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFeedURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		opml string
+		want []string
+	}{
+		{
+			name: "フラットな outline",
+			opml: `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0"><head><title>feeds</title></head><body>
+  <outline text="A" type="rss" xmlUrl="https://a.example.com/feed.xml"/>
+  <outline text="B" type="rss" xmlUrl=" https://b.example.com/rss "/>
+</body></opml>`,
+			want: []string{"https://a.example.com/feed.xml", "https://b.example.com/rss"},
+		},
+		{
+			name: "入れ子の outline (カテゴリ) を出現順に探索する",
+			opml: `<opml version="1.0"><body>
+  <outline text="ニュース">
+    <outline text="A" xmlUrl="https://a.example.com/feed.xml"/>
+    <outline text="国内">
+      <outline text="B" xmlUrl="https://b.example.com/feed.xml"/>
+    </outline>
+  </outline>
+  <outline text="C" xmlUrl="https://c.example.com/feed.xml">
+    <outline text="D" xmlUrl="https://d.example.com/feed.xml"/>
+  </outline>
+  <outline text="空のカテゴリ"/>
+</body></opml>`,
+			want: []string{"https://a.example.com/feed.xml", "https://b.example.com/feed.xml", "https://c.example.com/feed.xml", "https://d.example.com/feed.xml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFeedURLs(strings.NewReader(tt.opml))
+			if err != nil {
+				t.Fatalf("ParseFeedURLs() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseFeedURLs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFeedURLsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opml    string
+		wantErr string
+	}{
+		{"XMLではない", "not xml", "XMLデコードエラー"},
+		{"OPMLではないXML", `<rss version="2.0"></rss>`, "XMLデコードエラー"},
+		{"フィードURLがない", `<opml><body><outline text="カテゴリ"><outline text="x"/></outline></body></opml>`, "含まれていません"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFeedURLs(strings.NewReader(tt.opml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseFeedURLs() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
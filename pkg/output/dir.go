@@ -0,0 +1,154 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/shouni/web-text-pipe-go/pkg/article"
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+
+	iohandler "github.com/shouni/go-utils/iohandler"
+)
+
+const (
+	// IndexFileName は、出力ディレクトリに書き出されるマニフェストのファイル名です。
+	IndexFileName = "index.json"
+
+	maxSlugRunes   = 60 // ファイル名のスラッグ部分の最大文字数
+	hashSuffixLen  = 8  // 衝突回避用ハッシュサフィックスの長さ (16進数の文字数)
+	defaultSlug    = "article"
+	articleFileExt = ".txt"
+)
+
+// ManifestEntry は、出力ディレクトリ内の1記事分のファイル情報です。
+type ManifestEntry struct {
+	File  string   `json:"file"`
+	URL   string   `json:"url"`
+	Title string   `json:"title,omitempty"`
+	Feeds []string `json:"feeds,omitempty"`
+
+	Item *article.Item `json:"item,omitempty"` // フィードのアイテムのメタデータ
+	Page *article.Page `json:"page,omitempty"` // ページのメタデータ
+}
+
+// Manifest は、出力ディレクトリの index.json の内容です。
+type Manifest struct {
+	FeedTitle string          `json:"feed_title,omitempty"`
+	Articles  []ManifestEntry `json:"articles"`
+}
+
+// WriteDir は、成功した各記事の本文を個別のファイルとして dir に書き出し、
+// 書き出したファイルの一覧を index.json として保存します。
+// ファイル名は記事タイトルのスラッグと、URLから算出したハッシュサフィックスで構成されます。
+func WriteDir(dir string, result *runner.RunnerResult) (*Manifest, error) {
+	writer, err := NewDirWriter(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range result.Results {
+		if err := writer.Write(result, res); err != nil {
+			return nil, err
+		}
+	}
+	return writer.Finish(result)
+}
+
+// DirWriter は、成功した記事を1件ずつ個別のファイルとして書き出し、最後に index.json を保存します。
+// 結果が確定するたびに出力するストリーミング出力で使用します。
+type DirWriter struct {
+	dir      string
+	manifest *Manifest
+}
+
+// NewDirWriter は、出力ディレクトリを作成し、DirWriter を初期化します。
+func NewDirWriter(dir string) (*DirWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("出力ディレクトリの作成エラー (%s): %w", dir, err)
+	}
+	return &DirWriter{dir: dir, manifest: &Manifest{Articles: []ManifestEntry{}}}, nil
+}
+
+// Write は、成功した記事の本文をファイルに書き出し、マニフェストに追加します。失敗した記事は無視します。
+// 記事タイトルとフィードは result の TitlesMap・SourcesMap から取得します。
+func (w *DirWriter) Write(result *runner.RunnerResult, res runner.ScrapeResult) error {
+	if res.Error != nil || res.Content == "" {
+		return nil
+	}
+
+	title := result.TitlesMap[res.URL]
+	fileName := ArticleFileName(title, res.URL)
+
+	// iohandler パッケージを使用して出力 (exact コマンドと同じ書き出し処理)
+	if err := iohandler.WriteOutputString(filepath.Join(w.dir, fileName), res.Content); err != nil {
+		return fmt.Errorf("記事ファイルの書き込みエラー (URL: %s): %w", res.URL, err)
+	}
+
+	w.manifest.Articles = append(w.manifest.Articles, ManifestEntry{
+		File:  fileName,
+		URL:   res.URL,
+		Title: title,
+		Feeds: result.SourcesMap[res.URL],
+		Item:  result.FeedItem(res.URL),
+		Page:  res.Page,
+	})
+	return nil
+}
+
+// Finish は、書き出したファイルの一覧を index.json として保存し、マニフェストを返します。
+func (w *DirWriter) Finish(result *runner.RunnerResult) (*Manifest, error) {
+	w.manifest.FeedTitle = result.FeedTitle
+
+	index, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("マニフェストのJSONエンコードエラー: %w", err)
+	}
+	if err := iohandler.WriteOutputString(filepath.Join(w.dir, IndexFileName), string(index)+"\n"); err != nil {
+		return nil, fmt.Errorf("マニフェストの書き込みエラー: %w", err)
+	}
+
+	return w.manifest, nil
+}
+
+// ArticleFileName は、記事タイトルとURLから衝突しにくいファイル名を生成します。
+// 同じタイトルの記事が複数あっても、URLのハッシュによって別ファイルになります。
+func ArticleFileName(title, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	suffix := hex.EncodeToString(sum[:])[:hashSuffixLen]
+	return slugify(title) + "-" + suffix + articleFileExt
+}
+
+// slugify は、タイトルをファイル名に使用できる文字列に変換します。
+// 日本語などの文字はそのまま残し、記号や空白は '-' にまとめます。
+func slugify(title string) string {
+	var sb strings.Builder
+	runes := 0
+	lastHyphen := true // 先頭の '-' を抑止する
+
+	for _, r := range title {
+		if runes >= maxSlugRunes {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			sb.WriteRune(unicode.ToLower(r))
+			lastHyphen = false
+		} else if !lastHyphen {
+			sb.WriteRune('-')
+			lastHyphen = true
+		} else {
+			continue
+		}
+		runes++
+	}
+
+	slug := strings.TrimRight(sb.String(), "-")
+	if slug == "" {
+		return defaultSlug
+	}
+	return slug
+}
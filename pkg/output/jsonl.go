@@ -0,0 +1,55 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FormatText と FormatJSONL は、scraper コマンドがサポートする出力形式です。
+const (
+	FormatText  = "text"
+	FormatJSONL = "jsonl"
+)
+
+// EncodeJSONL は、Record のスライスを JSON Lines 形式 (1行1オブジェクト) の文字列に変換します。
+func EncodeJSONL(records []Record) (string, error) {
+	var sb strings.Builder
+	writer := NewJSONLWriter(&sb)
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			return "", err
+		}
+	}
+	return sb.String(), nil
+}
+
+// JSONLWriter は、Record を1件ずつ JSON Lines 形式で書き出します。
+// 結果が確定するたびに出力するストリーミング出力で使用します。
+type JSONLWriter struct {
+	encoder *json.Encoder
+	count   int
+}
+
+// NewJSONLWriter は、w に書き出す JSONLWriter を初期化します。
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	encoder := json.NewEncoder(w)
+	// 本文中の '<' や '&' をエスケープせず、そのまま出力する
+	encoder.SetEscapeHTML(false)
+	return &JSONLWriter{encoder: encoder}
+}
+
+// Write は、Record を1行のJSONとして書き出します。
+func (w *JSONLWriter) Write(record Record) error {
+	if err := w.encoder.Encode(record); err != nil {
+		return fmt.Errorf("JSONエンコードエラー (URL: %s): %w", record.URL, err)
+	}
+	w.count++
+	return nil
+}
+
+// Count は、書き出した Record の件数を返します。
+func (w *JSONLWriter) Count() int {
+	return w.count
+}
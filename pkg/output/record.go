@@ -0,0 +1,146 @@
+package output
+
+import (
+	"errors"
+
+	"github.com/shouni/web-text-pipe-go/pkg/article"
+
+	"github.com/shouni/web-text-pipe-go/pkg/pipeline"
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+)
+
+// 記事の抽出結果の状態です (Record.Status)。
+const (
+	StatusSucceeded          = "succeeded"
+	StatusFailed             = "failed"
+	StatusDisallowedByRobots = "disallowed_by_robots" // robots.txt で取得が許可されていないためスキップした
+	StatusDropped            = "dropped"              // 後処理のステージで除外した
+)
+
+// Record は、1記事分の抽出結果を構造化出力するためのデータ構造です。
+type Record struct {
+	URL       string   `json:"url"`
+	FeedTitle string   `json:"feed_title,omitempty"`
+	Feeds     []string `json:"feeds,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Content   string   `json:"content"`
+	Success   bool     `json:"success"`
+	Status    string   `json:"status"`
+	Error     string   `json:"error,omitempty"`
+	Attempts  int      `json:"attempts"`
+	Phase     string   `json:"phase"`
+	Charset   string   `json:"charset,omitempty"`
+
+	Metadata map[string]string `json:"metadata,omitempty"` // 後処理のステージが付加したメタデータ
+
+	Item *article.Item `json:"item,omitempty"` // フィードのアイテムのメタデータ (公開日時・著者・カテゴリ・GUID など)
+	Page *article.Page `json:"page,omitempty"` // ページのメタデータ (canonical・lang・OpenGraph・JSON-LD)
+}
+
+// NewRecords は、RunnerResult を記事単位の Record のスライスに変換します。
+func NewRecords(result *runner.RunnerResult) []Record {
+	records := make([]Record, 0, len(result.Results))
+	for _, res := range result.Results {
+		records = append(records, NewRecord(result, res))
+	}
+	return records
+}
+
+// NewRecord は、1記事分の結果を Record に変換します。
+// 記事タイトルは RunnerResult.TitlesMap から URL をキーに取得します。
+// フィードタイトルには、その記事を含んでいた最初のフィードのタイトルを使用します。
+// Runner.ScrapeAndStream のコールバックで、結果が確定するたびに変換するためにも使用します。
+func NewRecord(result *runner.RunnerResult, res runner.ScrapeResult) Record {
+	sources := result.SourcesMap[res.URL]
+	feedTitle := result.FeedTitle
+	if len(sources) > 0 {
+		feedTitle = ""
+		for _, summary := range result.Feeds {
+			if summary.URL == sources[0] {
+				feedTitle = summary.Title
+				break
+			}
+		}
+	}
+
+	record := Record{
+		URL:       res.URL,
+		FeedTitle: feedTitle,
+		Feeds:     sources,
+		Title:     result.TitlesMap[res.URL],
+		Content:   res.Content,
+		Success:   res.Error == nil,
+		Status:    statusOf(res),
+		Attempts:  res.Attempts,
+		Phase:     res.Phase,
+		Charset:   res.Charset,
+		Metadata:  res.Metadata,
+		Item:      result.FeedItem(res.URL),
+		Page:      res.Page,
+	}
+	if res.Error != nil {
+		record.Error = res.Error.Error()
+	}
+	return record
+}
+
+// statusOf は、記事の抽出結果の状態を返します。
+func statusOf(res runner.ScrapeResult) string {
+	switch {
+	case res.Error == nil:
+		return StatusSucceeded
+	case errors.Is(res.Error, runner.ErrDisallowedByRobots):
+		return StatusDisallowedByRobots
+	case errors.Is(res.Error, pipeline.ErrDropped):
+		return StatusDropped
+	default:
+		return StatusFailed
+	}
+}
+
+// FeedRecord は、フィード・サイトマップごとの取得結果を構造化出力するためのデータ構造です。
+type FeedRecord struct {
+	URL         string `json:"url"`
+	Kind        string `json:"kind"`
+	Title       string `json:"title,omitempty"`
+	ItemCount   int    `json:"item_count"`
+	NotModified bool   `json:"not_modified,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// NewFeedRecords は、RunnerResult.Feeds を FeedRecord のスライスに変換します。
+func NewFeedRecords(result *runner.RunnerResult) []FeedRecord {
+	records := make([]FeedRecord, 0, len(result.Feeds))
+	for _, summary := range result.Feeds {
+		record := FeedRecord{
+			URL:         summary.URL,
+			Kind:        summary.Kind,
+			Title:       summary.Title,
+			ItemCount:   summary.ItemCount,
+			NotModified: summary.NotModified,
+		}
+		if summary.Error != nil {
+			record.Error = summary.Error.Error()
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Result は、RunnerResult 全体を構造化出力するためのデータ構造です。
+type Result struct {
+	FeedTitle   string       `json:"feed_title"`
+	Feeds       []FeedRecord `json:"feeds"`
+	Results     []Record     `json:"results"`
+	NotModified bool         `json:"not_modified,omitempty"`
+}
+
+// NewResult は、RunnerResult をフィードごとの取得結果と記事単位の Record を持つ Result に変換します。
+func NewResult(result *runner.RunnerResult) *Result {
+	return &Result{
+		FeedTitle:   result.FeedTitle,
+		Feeds:       NewFeedRecords(result),
+		Results:     NewRecords(result),
+		NotModified: result.NotModified,
+	}
+}
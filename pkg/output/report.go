@@ -0,0 +1,124 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+
+	iohandler "github.com/shouni/go-utils/iohandler"
+)
+
+// Report は、1回の実行の結果をまとめた機械可読なレポートです (--report で出力)。
+type Report struct {
+	FeedTitle   string       `json:"feed_title,omitempty"`
+	StartedAt   time.Time    `json:"started_at"`
+	FinishedAt  time.Time    `json:"finished_at"`
+	Durations   Durations    `json:"durations"`
+	Feeds       []FeedRecord `json:"feeds,omitempty"`
+	NotModified bool         `json:"not_modified,omitempty"`
+
+	Total              int     `json:"total"`                // スクレイピングを実行した記事数 (robots.txt によるスキップを除く)
+	Succeeded          int     `json:"succeeded"`            // 抽出に成功した記事数
+	Failed             int     `json:"failed"`               // 最終的に抽出に失敗した記事数
+	Skipped            int     `json:"skipped"`              // 状態ストアに抽出済みとして記録されていたためスキップした記事数
+	DisallowedByRobots int     `json:"disallowed_by_robots"` // robots.txt で取得が許可されていないためスキップした記事数
+	Dropped            int     `json:"dropped"`              // 後処理のステージで除外した記事数 (Succeeded に含む)
+	FailureRatio       float64 `json:"failure_ratio"`        // Failed / Total (Total が0の場合は0)
+
+	FailuresByHost map[string]int `json:"failures_by_host"`
+	Retry          RetryStats     `json:"retry"`
+
+	Error string `json:"error,omitempty"` // 実行全体が失敗した場合のエラー
+}
+
+// Durations は、実行の各段階の所要時間 (秒) です。
+type Durations struct {
+	TotalSec     float64 `json:"total_seconds"`
+	FeedFetchSec float64 `json:"feed_fetch_seconds"`
+	ScrapeSec    float64 `json:"scrape_seconds"`
+}
+
+// RetryStats は、ワークフロー層のリトライの統計です。
+type RetryStats struct {
+	Retried       int `json:"retried"`        // リトライの対象になった記事数
+	Recovered     int `json:"recovered"`      // リトライで抽出に成功した記事数
+	TotalAttempts int `json:"total_attempts"` // 初回の並列抽出を含む、すべての記事の試行回数の合計
+}
+
+// NewReport は、RunnerResult と実行全体のエラーからレポートを生成します。
+// 実行全体が失敗した場合は runnerResult に nil を渡します。
+func NewReport(runnerResult *runner.RunnerResult, runErr error, startedAt, finishedAt time.Time) *Report {
+	report := &Report{
+		StartedAt:      startedAt,
+		FinishedAt:     finishedAt,
+		Durations:      Durations{TotalSec: finishedAt.Sub(startedAt).Seconds()},
+		FailuresByHost: make(map[string]int),
+	}
+	if runErr != nil {
+		report.Error = runErr.Error()
+	}
+	if runnerResult == nil {
+		return report
+	}
+
+	report.FeedTitle = runnerResult.FeedTitle
+	report.Feeds = NewFeedRecords(runnerResult)
+	report.NotModified = runnerResult.NotModified
+	report.Durations.FeedFetchSec = runnerResult.FeedFetchDuration.Seconds()
+	report.Durations.ScrapeSec = runnerResult.ScrapeDuration.Seconds()
+	report.Skipped = len(runnerResult.Skipped)
+
+	for _, res := range runnerResult.Results {
+		status := statusOf(res)
+		if status == StatusDisallowedByRobots {
+			report.DisallowedByRobots++
+			continue
+		}
+		report.Total++
+		report.Retry.TotalAttempts += res.Attempts
+		if res.Attempts > 1 {
+			report.Retry.Retried++
+		}
+		if status == StatusFailed {
+			report.Failed++
+			report.FailuresByHost[hostOf(res.URL)]++
+			continue
+		}
+		// 後処理のステージで除外した記事も、抽出には成功しているため成功として数える
+		report.Succeeded++
+		if status == StatusDropped {
+			report.Dropped++
+		}
+		if res.Attempts > 1 {
+			report.Retry.Recovered++
+		}
+	}
+	if report.Total > 0 {
+		report.FailureRatio = float64(report.Failed) / float64(report.Total)
+	}
+	return report
+}
+
+// WriteReport は、レポートを整形済みJSONとしてファイルに出力します。path が空の場合は標準出力に出力します。
+func WriteReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("レポートのJSONエンコードエラー: %w", err)
+	}
+	if err := iohandler.WriteOutputString(path, string(data)+"\n"); err != nil {
+		return fmt.Errorf("レポートの書き込みエラー (%s): %w", path, err)
+	}
+	return nil
+}
+
+// hostOf は、レポートの集計に使用するURLのホスト名を返します。解析できない場合はURLをそのまま返します。
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return rawURL
+	}
+	return u.Hostname()
+}
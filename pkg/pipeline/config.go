@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory は、設定ファイルのステージの設定 (type・name を除いたJSONオブジェクト) からステージを構築します。
+type Factory func(params json.RawMessage) (Stage, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register は、設定ファイルの "type" で指定できるステージの種類を登録します。
+// 組み込みのステージ以外を設定ファイルから使用する場合に、プログラムの初期化時に呼び出します。
+// 同じ種類を二重に登録した場合は panic します。
+func Register(typ string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("pipeline: Register の factory が nil です (type: " + typ + ")")
+	}
+	if _, dup := registry[typ]; dup {
+		panic("pipeline: ステージの種類が二重に登録されました (type: " + typ + ")")
+	}
+	registry[typ] = factory
+}
+
+// Types は、登録されているステージの種類を名前順で返します。
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	types := make([]string, 0, len(registry))
+	for typ := range registry {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Config は、後処理のパイプラインの設定ファイル (JSON) の内容です。
+//
+//	{"stages": [
+//	  {"type": "min-length", "min_chars": 200},
+//	  {"type": "filter", "name": "drop-pr", "field": "title", "pattern": "^\\[PR\\]", "exclude": true},
+//	  {"type": "jsonl", "path": "articles.jsonl"}
+//	]}
+type Config struct {
+	// Stages は、適用する順に並べたステージの設定です。
+	// 各要素の "type" (必須) でステージの種類を、"name" (省略時は type) でログやエラーに表示する名前を指定し、
+	// それ以外のキーはステージの種類ごとの設定として Factory に渡されます。
+	Stages []json.RawMessage `json:"stages"`
+}
+
+// Load は、JSON 形式の設定ファイルを読み込みます。
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("パイプライン設定ファイルの読み込みエラー (%s): %w", path, err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("パイプライン設定ファイルのJSONデコードエラー (%s): %w", path, err)
+	}
+	return config, nil
+}
+
+// Build は、設定に従ってステージを構築し、Pipeline を返します。
+// ステージの構築に失敗した場合は、それまでに構築したステージを閉じてからエラーを返します。
+func Build(config Config) (*Pipeline, error) {
+	p := New()
+	for i, raw := range config.Stages {
+		name, stage, err := buildStage(raw)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("%d番目のステージ: %w", i+1, err), p.Close())
+		}
+		p.Append(name, stage)
+	}
+	return p, nil
+}
+
+// buildStage は、1つのステージの設定から "type" と "name" を取り出し、残りの設定で Factory を呼び出します。
+func buildStage(raw json.RawMessage) (string, Stage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return "", nil, fmt.Errorf("ステージの設定はJSONオブジェクトで指定してください: %w", err)
+	}
+
+	var typ, name string
+	if err := unmarshalField(fields, "type", &typ); err != nil {
+		return "", nil, err
+	}
+	if err := unmarshalField(fields, "name", &name); err != nil {
+		return "", nil, err
+	}
+	if typ == "" {
+		return "", nil, fmt.Errorf("ステージの種類 (type) が指定されていません")
+	}
+	if name == "" {
+		name = typ
+	}
+
+	registryMu.RLock()
+	factory, ok := registry[typ]
+	registryMu.RUnlock()
+	if !ok {
+		return "", nil, fmt.Errorf("未対応のステージの種類です (type: %s)。%s のいずれかを指定してください", typ, strings.Join(Types(), ", "))
+	}
+
+	delete(fields, "type")
+	delete(fields, "name")
+	params, err := json.Marshal(fields)
+	if err != nil {
+		return "", nil, err
+	}
+	stage, err := factory(params)
+	if err != nil {
+		return "", nil, fmt.Errorf("ステージ %s (type: %s) の構築エラー: %w", name, typ, err)
+	}
+	return name, stage, nil
+}
+
+// unmarshalField は、JSONオブジェクトのフィールドが存在する場合にデコードします。
+func unmarshalField(fields map[string]json.RawMessage, key string, v any) error {
+	raw, ok := fields[key]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%s のデコードエラー: %w", key, err)
+	}
+	return nil
+}
+
+// DecodeParams は、ステージの設定を v にデコードします。未知のキーはエラーとします (設定の誤記を検出するため)。
+// Factory の実装で使用します。
+func DecodeParams(params json.RawMessage, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("ステージの設定のデコードエラー: %w", err)
+	}
+	return nil
+}
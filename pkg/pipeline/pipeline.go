@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/shouni/web-text-pipe-go/pkg/article"
+)
+
+// ErrDropped は、後処理のステージで記事が除外されたことを表します。
+// Pipeline.Process が返すエラーは、除外したステージ名を含めてこのエラーをラップします。
+var ErrDropped = errors.New("後処理のステージで除外されました")
+
+// Record は、ステージ間で受け渡す1記事分のデータです。
+type Record struct {
+	URL      string            `json:"url"`
+	Title    string            `json:"title,omitempty"`
+	Feeds    []string          `json:"feeds,omitempty"` // その記事を含んでいたフィードのURL
+	Content  string            `json:"content"`
+	Charset  string            `json:"charset,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"` // ステージが付加する任意のメタデータ
+
+	// Item と Page は参照用の情報です。ステージで変更しても抽出結果には反映されません。
+	Item *article.Item `json:"item,omitempty"` // フィードのアイテムのメタデータ (フィードに含まれていない記事は nil)
+	Page *article.Page `json:"page,omitempty"` // ページのメタデータ (canonical・lang・OpenGraph・JSON-LD)
+}
+
+// Stage は、抽出後の記事に適用する後処理の1段階です (フィルター・正規化・情報の付加・外部への出力など)。
+// keep が false の場合、その記事を除外し、後続のステージには渡しません。
+// 複数のゴルーチンから同時に呼び出される場合があります。
+// io.Closer を実装するステージは、Pipeline.Close で閉じられます。
+type Stage interface {
+	Process(ctx context.Context, record Record) (out Record, keep bool, err error)
+}
+
+// StageFunc は、関数を Stage として使用するためのアダプターです。
+type StageFunc func(ctx context.Context, record Record) (Record, bool, error)
+
+func (f StageFunc) Process(ctx context.Context, record Record) (Record, bool, error) {
+	return f(ctx, record)
+}
+
+// namedStage は、ログやエラーで識別するための名前を持つステージです。
+type namedStage struct {
+	name  string
+	stage Stage
+}
+
+// Pipeline は、複数のステージを順に適用します。
+// nil の Pipeline は記事をそのまま返します。
+type Pipeline struct {
+	stages []namedStage
+}
+
+// New は、空の Pipeline を初期化します。ステージは Append で追加します。
+func New() *Pipeline {
+	return &Pipeline{}
+}
+
+// Append は、名前を付けたステージを末尾に追加します。
+func (p *Pipeline) Append(name string, stage Stage) *Pipeline {
+	p.stages = append(p.stages, namedStage{name: name, stage: stage})
+	return p
+}
+
+// Len は、ステージの数を返します。
+func (p *Pipeline) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.stages)
+}
+
+// Process は、記事にすべてのステージを順に適用します。
+// いずれかのステージが除外した場合は ErrDropped をラップしたエラーを、
+// ステージが失敗した場合はステージ名を含むエラーを返し、以降のステージは適用しません。
+func (p *Pipeline) Process(ctx context.Context, record Record) (Record, error) {
+	if p == nil {
+		return record, nil
+	}
+	for _, s := range p.stages {
+		if err := context.Cause(ctx); err != nil {
+			return record, err
+		}
+		out, keep, err := s.stage.Process(ctx, record)
+		if err != nil {
+			return record, fmt.Errorf("ステージ %s の処理エラー: %w", s.name, err)
+		}
+		if !keep {
+			return record, fmt.Errorf("%w (ステージ: %s)", ErrDropped, s.name)
+		}
+		record = out
+	}
+	return record, nil
+}
+
+// Close は、io.Closer を実装するステージ (ファイルへの出力など) をすべて閉じます。
+func (p *Pipeline) Close() error {
+	if p == nil {
+		return nil
+	}
+	var errs []error
+	for _, s := range p.stages {
+		if closer, ok := s.stage.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("ステージ %s の終了エラー: %w", s.name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
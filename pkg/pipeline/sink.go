@@ -0,0 +1,136 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- jsonl ---
+
+// JSONLStage は、記事を JSON Lines 形式でファイルに追記し、記事をそのまま後続のステージに渡すステージです。
+// 複数のゴルーチンから同時に呼び出しても、1行ずつ書き込まれます。
+type JSONLStage struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// NewJSONLStage は、path のファイルに追記する JSONLStage を初期化します。ファイルが存在しない場合は作成します。
+func NewJSONLStage(path string) (*JSONLStage, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("出力ファイルのオープンエラー (%s): %w", path, err)
+	}
+	encoder := json.NewEncoder(f)
+	// 本文中の '<' や '&' をエスケープせず、そのまま出力する
+	encoder.SetEscapeHTML(false)
+	return &JSONLStage{file: f, encoder: encoder}, nil
+}
+
+func (s *JSONLStage) Process(_ context.Context, record Record) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.encoder.Encode(record); err != nil {
+		return record, false, fmt.Errorf("出力ファイルへの書き込みエラー (URL: %s): %w", record.URL, err)
+	}
+	return record, true, nil
+}
+
+// Close は、出力ファイルを閉じます。
+func (s *JSONLStage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+func newJSONLStage(params json.RawMessage) (Stage, error) {
+	var config struct {
+		Path string `json:"path"`
+	}
+	if err := DecodeParams(params, &config); err != nil {
+		return nil, err
+	}
+	if config.Path == "" {
+		return nil, fmt.Errorf("path に出力先のファイルを指定してください")
+	}
+	return NewJSONLStage(config.Path)
+}
+
+// --- exec ---
+
+// ExecStage は、外部コマンドで記事を処理するステージです。
+// 記事ごとにコマンドを実行し、標準入力に Record のJSONを渡して、標準出力から処理後の Record のJSONを受け取ります。
+// 標準出力が空の場合は記事を除外し、コマンドが非ゼロで終了した場合はエラーとします。
+type ExecStage struct {
+	command []string
+	timeout time.Duration // 0 の場合はコンテキストのタイムアウトのみ
+}
+
+// NewExecStage は、ExecStage を初期化します。command の先頭要素が実行するプログラムです。
+func NewExecStage(command []string, timeout time.Duration) (*ExecStage, error) {
+	if len(command) == 0 || command[0] == "" {
+		return nil, fmt.Errorf("command に実行するコマンドを指定してください")
+	}
+	return &ExecStage{command: command, timeout: timeout}, nil
+}
+
+func (s *ExecStage) Process(ctx context.Context, record Record) (Record, bool, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	input, err := json.Marshal(record)
+	if err != nil {
+		return record, false, fmt.Errorf("記事のJSONエンコードエラー (URL: %s): %w", record.URL, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.command[0], s.command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return record, false, fmt.Errorf("コマンドの実行エラー (URL: %s): %w: %s", record.URL, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return record, false, nil
+	}
+	var out Record
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return record, false, fmt.Errorf("コマンドの出力のJSONデコードエラー (URL: %s): %w", record.URL, err)
+	}
+	if out.URL == "" {
+		// URL を省略した出力は、入力の記事の処理結果として扱う
+		out.URL = record.URL
+	}
+	return out, true, nil
+}
+
+func newExecStage(params json.RawMessage) (Stage, error) {
+	var config struct {
+		Command []string `json:"command"`
+		Timeout string   `json:"timeout"`
+	}
+	if err := DecodeParams(params, &config); err != nil {
+		return nil, err
+	}
+	var timeout time.Duration
+	if config.Timeout != "" {
+		d, err := time.ParseDuration(config.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("無効な timeout です (%s)。正の期間 (例: 10s) を指定してください", config.Timeout)
+		}
+		timeout = d
+	}
+	return NewExecStage(config.Command, timeout)
+}
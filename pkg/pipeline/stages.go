@@ -0,0 +1,169 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/shouni/web-text-pipe-go/pkg/normalize"
+)
+
+// 組み込みのステージの種類です (設定ファイルの "type")。
+const (
+	TypeNormalize = "normalize"  // 本文の正規化 (normalize パッケージ)
+	TypeMinLength = "min-length" // 本文が短い記事の除外
+	TypeFilter    = "filter"     // 正規表現による記事の絞り込み
+	TypeMetadata  = "metadata"   // 固定のメタデータの付加
+	TypeCharCount = "char-count" // 本文の文字数をメタデータに付加
+	TypeJSONL     = "jsonl"      // JSON Lines 形式でのファイルへの出力
+	TypeExec      = "exec"       // 外部コマンドによる処理
+)
+
+// CharCountKey は、char-count ステージが本文の文字数を設定するメタデータのキーです。
+const CharCountKey = "char_count"
+
+func init() {
+	Register(TypeNormalize, newNormalizeStage)
+	Register(TypeMinLength, newMinLengthStage)
+	Register(TypeFilter, newFilterStage)
+	Register(TypeMetadata, newMetadataStage)
+	Register(TypeCharCount, func(json.RawMessage) (Stage, error) { return StageFunc(countChars), nil })
+	Register(TypeJSONL, newJSONLStage)
+	Register(TypeExec, newExecStage)
+}
+
+// --- normalize ---
+
+// NormalizeStage は、本文を normalize.Normalizer で正規化するステージを返します。
+func NormalizeStage(normalizer *normalize.Normalizer) Stage {
+	return StageFunc(func(_ context.Context, record Record) (Record, bool, error) {
+		record.Content = normalizer.Normalize(record.Content)
+		return record, true, nil
+	})
+}
+
+func newNormalizeStage(params json.RawMessage) (Stage, error) {
+	var config struct {
+		NFKC               bool `json:"nfkc"`
+		CollapseWhitespace bool `json:"collapse_whitespace"`
+		StripEmoji         bool `json:"strip_emoji"`
+	}
+	if err := DecodeParams(params, &config); err != nil {
+		return nil, err
+	}
+	opts := normalize.Options{NFKC: config.NFKC, CollapseWhitespace: config.CollapseWhitespace, StripEmoji: config.StripEmoji}
+	if !opts.Enabled() {
+		return nil, fmt.Errorf("nfkc, collapse_whitespace, strip_emoji のいずれかを true にしてください")
+	}
+	return NormalizeStage(normalize.New(opts)), nil
+}
+
+// --- min-length ---
+
+// MinLengthStage は、本文の文字数 (バイト数ではない) が minChars 未満の記事を除外するステージを返します。
+func MinLengthStage(minChars int) Stage {
+	return StageFunc(func(_ context.Context, record Record) (Record, bool, error) {
+		return record, utf8.RuneCountInString(record.Content) >= minChars, nil
+	})
+}
+
+func newMinLengthStage(params json.RawMessage) (Stage, error) {
+	var config struct {
+		MinChars int `json:"min_chars"`
+	}
+	if err := DecodeParams(params, &config); err != nil {
+		return nil, err
+	}
+	if config.MinChars <= 0 {
+		return nil, fmt.Errorf("min_chars には 1 以上の値を指定してください (指定値: %d)", config.MinChars)
+	}
+	return MinLengthStage(config.MinChars), nil
+}
+
+// --- filter ---
+
+// FilterStage は、記事のフィールド ("url" / "title" / "content") が正規表現に一致するかどうかで絞り込むステージを返します。
+// exclude が false の場合は一致する記事のみを残し、true の場合は一致する記事を除外します。
+func FilterStage(field string, pattern *regexp.Regexp, exclude bool) (Stage, error) {
+	get, err := fieldGetter(field)
+	if err != nil {
+		return nil, err
+	}
+	return StageFunc(func(_ context.Context, record Record) (Record, bool, error) {
+		return record, pattern.MatchString(get(record)) != exclude, nil
+	}), nil
+}
+
+func newFilterStage(params json.RawMessage) (Stage, error) {
+	var config struct {
+		Field   string `json:"field"`
+		Pattern string `json:"pattern"`
+		Exclude bool   `json:"exclude"`
+	}
+	if err := DecodeParams(params, &config); err != nil {
+		return nil, err
+	}
+	pattern, err := regexp.Compile(config.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("pattern の正規表現が無効です: %w", err)
+	}
+	return FilterStage(config.Field, pattern, config.Exclude)
+}
+
+// fieldGetter は、フィルターの対象とする記事のフィールドの値を返す関数を返します。
+func fieldGetter(field string) (func(Record) string, error) {
+	switch field {
+	case "url":
+		return func(r Record) string { return r.URL }, nil
+	case "title":
+		return func(r Record) string { return r.Title }, nil
+	case "content":
+		return func(r Record) string { return r.Content }, nil
+	default:
+		return nil, fmt.Errorf("無効なフィールドです (field: %q)。url, title, content のいずれかを指定してください", field)
+	}
+}
+
+// --- metadata / char-count ---
+
+// MetadataStage は、固定のメタデータを記事に付加するステージを返します (同名のキーは上書きします)。
+func MetadataStage(values map[string]string) Stage {
+	return StageFunc(func(_ context.Context, record Record) (Record, bool, error) {
+		record.Metadata = withMetadata(record.Metadata, values)
+		return record, true, nil
+	})
+}
+
+func newMetadataStage(params json.RawMessage) (Stage, error) {
+	var config struct {
+		Values map[string]string `json:"values"`
+	}
+	if err := DecodeParams(params, &config); err != nil {
+		return nil, err
+	}
+	if len(config.Values) == 0 {
+		return nil, fmt.Errorf("values に付加するメタデータを指定してください")
+	}
+	return MetadataStage(config.Values), nil
+}
+
+// countChars は、本文の文字数をメタデータ CharCountKey に設定します。
+func countChars(_ context.Context, record Record) (Record, bool, error) {
+	record.Metadata = withMetadata(record.Metadata, map[string]string{
+		CharCountKey: strconv.Itoa(utf8.RuneCountInString(record.Content)),
+	})
+	return record, true, nil
+}
+
+// withMetadata は、メタデータの複製に values を追加したマップを返します。
+// Record は値で受け渡されるため、呼び出し元のマップを変更しないよう複製します。
+func withMetadata(metadata, values map[string]string) map[string]string {
+	merged := make(map[string]string, len(metadata)+len(values))
+	maps.Copy(merged, metadata)
+	maps.Copy(merged, values)
+	return merged
+}
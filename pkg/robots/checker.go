@@ -0,0 +1,179 @@
+package robots
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultUserAgent は、robots.txt の User-agent と照合するデフォルトのトークンです。
+	DefaultUserAgent = "web-text-pipe"
+	// DefaultCacheTTL は、取得した robots.txt をキャッシュするデフォルトの期間です (RFC 9309 の推奨上限)。
+	DefaultCacheTTL = 24 * time.Hour
+
+	// unreachableTTL は、robots.txt を取得できなかった (すべて不許可とした) 結果をキャッシュする期間です。
+	unreachableTTL = 10 * time.Minute
+	// maxBodyBytes は、解析する robots.txt の最大バイト数です (RFC 9309 の下限値)。
+	maxBodyBytes = 500 * 1024
+)
+
+// entry は、1オリジン分の robots.txt の取得結果です。
+type entry struct {
+	ready       chan struct{} // 取得の完了時に close される
+	group       Group
+	disallowAll bool
+	expiresAt   time.Time
+}
+
+// Checker は、robots.txt に基づいてURLへのアクセスが許可されているかを判定します。
+// robots.txt はオリジン (スキームとホスト) ごとに一度だけ取得してキャッシュし、
+// 複数のゴルーチンから安全に使用できます。
+type Checker struct {
+	client    *http.Client
+	userAgent string
+	ttl       time.Duration
+
+	// OnCrawlDelay が設定されている場合、Crawl-delay が指定された robots.txt を取得するたびに、
+	// ホスト名と待機時間を通知します (ホストごとのレート制限への反映に使用します)。
+	OnCrawlDelay func(host string, delay time.Duration)
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// NewChecker は Checker を初期化します。
+// userAgent が空の場合は DefaultUserAgent を、ttl が0以下の場合は DefaultCacheTTL を使用します。
+func NewChecker(client *http.Client, userAgent string, ttl time.Duration) *Checker {
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Checker{
+		client:    client,
+		userAgent: userAgent,
+		ttl:       ttl,
+		entries:   make(map[string]*entry),
+	}
+}
+
+// Allowed は、URLへのアクセスが robots.txt で許可されているかを返します。
+// robots.txt が存在しない (4xx) 場合はすべて許可し、サーバーエラーや通信エラーで取得できない場合は
+// RFC 9309 に従ってすべて不許可とします。解析できないURLは許可として扱います (抽出時にエラーになります)。
+func (c *Checker) Allowed(ctx context.Context, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return true
+	}
+
+	e, err := c.entry(ctx, u)
+	if err != nil {
+		// robots.txt の取得を待機中にコンテキストがキャンセルされた場合は、抽出も行われないため許可として扱う
+		return true
+	}
+	if e.disallowAll {
+		return false
+	}
+	return e.group.Allowed(u.EscapedPath() + queryOf(u))
+}
+
+// entry は、URLのオリジンの robots.txt の取得結果を返します。
+// キャッシュがない、または期限切れの場合は取得し、同じオリジンの取得中は完了を待機します。
+func (c *Checker) entry(ctx context.Context, u *url.URL) (*entry, error) {
+	origin := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	c.mu.Lock()
+	e, ok := c.entries[origin]
+	if ok {
+		select {
+		case <-e.ready:
+			if time.Now().After(e.expiresAt) {
+				ok = false
+			}
+		default:
+			// 取得中
+		}
+	}
+	if !ok {
+		e = &entry{ready: make(chan struct{})}
+		c.entries[origin] = e
+		c.mu.Unlock()
+
+		// 最初の呼び出し元のキャンセルが他の呼び出し元の判定に影響しないよう、キャンセルを引き継がずに取得する
+		c.fetch(context.WithoutCancel(ctx), origin, u.Hostname(), e)
+		close(e.ready)
+		return e, nil
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-e.ready:
+		return e, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch は、オリジンの robots.txt を取得・解析し、結果を e に設定します。
+func (c *Checker) fetch(ctx context.Context, origin, host string, e *entry) {
+	robotsURL := origin + "/robots.txt"
+	group, err := c.get(ctx, robotsURL)
+	if err != nil {
+		slog.Warn("robots.txt を取得できないため、このホストの記事はすべてスキップします。", slog.String("url", robotsURL), slog.Any("cause", err))
+		e.disallowAll = true
+		e.expiresAt = time.Now().Add(min(c.ttl, unreachableTTL))
+		return
+	}
+
+	e.group = group
+	e.expiresAt = time.Now().Add(c.ttl)
+	if group.CrawlDelay > 0 && c.OnCrawlDelay != nil {
+		c.OnCrawlDelay(strings.ToLower(host), group.CrawlDelay)
+	}
+}
+
+// get は robots.txt を取得し、このクローラーに適用されるグループを返します。
+// User-Agent にはトークンを設定しますが、クライアントのトランスポートが User-Agent を設定する場合 (--user-agent) はそちらが優先されます。
+// robots.txt が存在しない (4xx) 場合は空のグループ (すべて許可) を返します。
+func (c *Checker) get(ctx context.Context, robotsURL string) (Group, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return Group{}, fmt.Errorf("robots.txt のリクエスト作成エラー: %w", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Group{}, fmt.Errorf("robots.txt の取得エラー: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+		if err != nil {
+			return Group{}, fmt.Errorf("robots.txt の読み込みエラー: %w", err)
+		}
+		return Parse(body, c.userAgent), nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return Group{}, nil
+	default:
+		return Group{}, fmt.Errorf("robots.txt の取得エラー: ステータスコード %d", resp.StatusCode)
+	}
+}
+
+// queryOf は、パスとの照合に使用するクエリ文字列 ('?' を含む) を返します。
+func queryOf(u *url.URL) string {
+	if u.RawQuery == "" {
+		return ""
+	}
+	return "?" + u.RawQuery
+}
@@ -0,0 +1,161 @@
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Group は、robots.txt のうち、クローラーに適用される1グループ分の規則です。
+type Group struct {
+	Rules      []Rule
+	CrawlDelay time.Duration // Crawl-delay の指定 (指定がない場合は0)
+}
+
+// Rule は、Allow / Disallow の1行分の規則です。
+type Rule struct {
+	Allow   bool
+	Pattern string // '*' (任意の文字列) と末尾の '$' (パスの終端) を含むパスのパターン
+}
+
+// Parse は robots.txt を解析し、userAgent のトークンに適用されるグループを返します。
+// RFC 9309 に従い、プロダクトトークンが大文字・小文字を区別せず一致するグループ (複数ある場合は結合) を優先し、
+// 一致するグループがない場合は "*" のグループを使用します。どちらもない場合は空のグループを返します。
+func Parse(body []byte, userAgent string) Group {
+	token := productToken(userAgent)
+
+	var matched, wildcard Group
+	var hasMatched bool
+
+	// 現在のグループが対象とする User-agent の一致状況
+	var groupMatches, groupWildcard, inRules bool
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// 規則の後に現れた User-agent は新しいグループの開始
+			if inRules {
+				groupMatches, groupWildcard, inRules = false, false, false
+			}
+			agent := productToken(value)
+			switch {
+			case value == "*":
+				groupWildcard = true
+			case agent != "" && agent == token:
+				groupMatches = true
+				hasMatched = true
+			}
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				// 空の Disallow は「すべて許可」を意味するため、規則として扱わない
+				continue
+			}
+			rule := Rule{Allow: key == "allow", Pattern: value}
+			if groupMatches {
+				matched.Rules = append(matched.Rules, rule)
+			}
+			if groupWildcard {
+				wildcard.Rules = append(wildcard.Rules, rule)
+			}
+		case "crawl-delay":
+			inRules = true
+			delay, err := strconv.ParseFloat(value, 64)
+			if err != nil || delay <= 0 {
+				continue
+			}
+			d := time.Duration(delay * float64(time.Second))
+			if groupMatches {
+				matched.CrawlDelay = d
+			}
+			if groupWildcard {
+				wildcard.CrawlDelay = d
+			}
+		}
+	}
+
+	if hasMatched {
+		return matched
+	}
+	return wildcard
+}
+
+// productToken は、User-Agent の先頭のプロダクトトークン (英字・'_'・'-' の並び) を小文字で返します。
+// "web-text-pipe/1.0" のようにバージョンなどが続く場合も "web-text-pipe" として照合します。
+func productToken(userAgent string) string {
+	userAgent = strings.ToLower(strings.TrimSpace(userAgent))
+	end := strings.IndexFunc(userAgent, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r == '_' || r == '-')
+	})
+	if end < 0 {
+		return userAgent
+	}
+	return userAgent[:end]
+}
+
+// Allowed は、パス (クエリを含む) へのアクセスがグループの規則で許可されているかを返します。
+// 最も長いパターンに一致した規則を優先し、長さが同じ場合は Allow を優先します。
+func (g Group) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed := true
+	longest := -1
+	for _, rule := range g.Rules {
+		if !match(rule.Pattern, path) {
+			continue
+		}
+		length := len(rule.Pattern)
+		if length > longest || (length == longest && rule.Allow) {
+			longest = length
+			allowed = rule.Allow
+		}
+	}
+	return allowed
+}
+
+// match は、パスがパターンに前方一致するかを返します。
+// パターン中の '*' は任意の文字列に、末尾の '$' はパスの終端に一致します。
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	// 最初の部分はパスの先頭に一致する必要がある
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+
+	for i, part := range parts[1:] {
+		last := i == len(parts)-2
+		if last && anchored {
+			return strings.HasSuffix(rest, part)
+		}
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+		rest = rest[idx+len(part):]
+	}
+
+	if anchored && len(parts) == 1 {
+		return rest == ""
+	}
+	return true
+}
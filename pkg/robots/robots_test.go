@@ -0,0 +1,152 @@
+package robots
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	const body = `# コメント
+User-agent: *
+Disallow: /private/
+Crawl-delay: 1
+
+User-agent: Web-Text-Pipe/1.0
+User-agent: other-bot
+Disallow: /tmp/
+Allow: /tmp/public
+Crawl-delay: 2.5
+
+User-agent: web-text-pipe
+Disallow: /drafts/
+`
+
+	tests := []struct {
+		name      string
+		userAgent string
+		allowed   map[string]bool
+		delay     time.Duration
+	}{
+		{
+			name:      "プロダクトトークンは大文字・小文字を区別せず一致し、一致するグループは結合される",
+			userAgent: "web-text-pipe",
+			allowed: map[string]bool{
+				"/tmp/a":          false,
+				"/tmp/public/a":   true,
+				"/drafts/a":       false,
+				"/private/a":      true,
+				"/robots.txt":     true,
+				"/index.html":     true,
+				"":                true,
+				"/tmp/public?q=1": true,
+			},
+			delay: 2500 * time.Millisecond,
+		},
+		{
+			name:      "指定したトークンのバージョンは照合に使用しない",
+			userAgent: "WEB-TEXT-PIPE/2.0",
+			allowed:   map[string]bool{"/drafts/a": false, "/private/a": true},
+			delay:     2500 * time.Millisecond,
+		},
+		{
+			name:      "一致するグループがない場合は * のグループを使用する",
+			userAgent: "unknown-bot",
+			allowed:   map[string]bool{"/private/a": false, "/tmp/a": true},
+			delay:     time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := Parse([]byte(body), tt.userAgent)
+			for path, want := range tt.allowed {
+				if got := group.Allowed(path); got != want {
+					t.Errorf("Allowed(%q) = %v, want %v", path, got, want)
+				}
+			}
+			if group.CrawlDelay != tt.delay {
+				t.Errorf("CrawlDelay = %v, want %v", group.CrawlDelay, tt.delay)
+			}
+		})
+	}
+}
+
+func TestParseWithoutGroups(t *testing.T) {
+	group := Parse([]byte("Sitemap: https://example.com/sitemap.xml\n"), DefaultUserAgent)
+	if len(group.Rules) != 0 || group.CrawlDelay != 0 {
+		t.Fatalf("Parse() = %+v, want empty group", group)
+	}
+	if !group.Allowed("/any") {
+		t.Error("Allowed(/any) = false, want true")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/", "/anything", true},
+		{"/fish", "/fish", true},
+		{"/fish", "/fish.html", true},
+		{"/fish", "/Fish", false},
+		{"/fish", "/catfish", false},
+		{"/*.php", "/index.php", true},
+		{"/*.php", "/dir/index.php?q=1", true},
+		{"/*.php", "/index.html", false},
+		{"/*.php$", "/index.php", true},
+		{"/*.php$", "/index.php?q=1", false},
+		{"/fish*", "/fish/salmon", true},
+		{"/fish$", "/fish", true},
+		{"/fish$", "/fish/", false},
+		{"/a*b*c", "/axxbyyc", true},
+		{"/a*b*c", "/axxcyyb", false},
+		{"/a*c$", "/abcabc", true},
+		{"/a*c$", "/abcab", false},
+		{"*", "/", true},
+		{"$", "", true},
+	}
+
+	for _, tt := range tests {
+		if got := match(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGroupAllowedPrecedence(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []Rule
+		path  string
+		want  bool
+	}{
+		{
+			name:  "最も長いパターンを優先する",
+			rules: []Rule{{Allow: false, Pattern: "/page"}, {Allow: true, Pattern: "/page/public"}},
+			path:  "/page/public/1",
+			want:  true,
+		},
+		{
+			name:  "長さが同じ場合は Allow を優先する",
+			rules: []Rule{{Allow: false, Pattern: "/page"}, {Allow: true, Pattern: "/page"}},
+			path:  "/page",
+			want:  true,
+		},
+		{
+			name:  "ワイルドカードを含むパターンも長さで比較する",
+			rules: []Rule{{Allow: true, Pattern: "/"}, {Allow: false, Pattern: "/*.pdf$"}},
+			path:  "/docs/a.pdf",
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Group{Rules: tt.rules}).Allowed(tt.path); got != tt.want {
+				t.Errorf("Allowed(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/feedcache"
+
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+// syndicationPeriods は、RSS 1.0 Syndication モジュールの sy:updatePeriod の値と期間の対応です。
+var syndicationPeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// feedUpdateInterval は、フィードが宣言する更新間隔 (<ttl> または sy:updatePeriod / sy:updateFrequency) を返します。
+// gofeed の共通フィード型は <ttl> を保持しないため、<ttl> は feedcache.ConditionalParser が Custom に格納した値を参照します。
+// 両方が宣言されている場合は長い方を、いずれも宣言されていない場合はゼロ値を返します。
+func feedUpdateInterval(f *gofeed.Feed) time.Duration {
+	var interval time.Duration
+
+	if minutes, err := strconv.Atoi(strings.TrimSpace(f.Custom[feedcache.CustomKeyTTL])); err == nil && minutes > 0 {
+		interval = time.Duration(minutes) * time.Minute
+	}
+
+	if sy, ok := f.Extensions["sy"]; ok {
+		period := syndicationPeriods[strings.ToLower(strings.TrimSpace(extensionValue(sy, "updatePeriod")))]
+		frequency, err := strconv.Atoi(strings.TrimSpace(extensionValue(sy, "updateFrequency")))
+		if err != nil || frequency <= 0 {
+			frequency = 1
+		}
+		if period > 0 {
+			interval = max(interval, period/time.Duration(frequency))
+		}
+	}
+
+	return interval
+}
+
+// extensionValue は、名前空間付き拡張要素の最初の値を返します。
+func extensionValue(extensions map[string][]ext.Extension, name string) string {
+	if values := extensions[name]; len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
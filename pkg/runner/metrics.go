@@ -0,0 +1,41 @@
+package runner
+
+import "time"
+
+// ----------------------------------------------------------------
+// メトリクス (MetricsRecorder) - 抽出パイプラインの計測
+// ----------------------------------------------------------------
+
+// MetricsRecorder は、抽出パイプラインのメトリクスの記録先です。
+// metrics.Metrics がこのインターフェースを実装します。
+type MetricsRecorder interface {
+	// ArticleAttempted, ArticleSucceeded, ArticleFailed は、フェーズ (ResultPhaseParallel / ResultPhaseRetry) ごとの
+	// 記事の抽出試行・成功・失敗を記録します。
+	ArticleAttempted(url, phase string)
+	ArticleSucceeded(url, phase string)
+	ArticleFailed(url, phase string)
+	// RetrySucceeded は、attempt 回目のリトライで抽出に成功したことを記録します。
+	RetrySucceeded(url string, attempt int)
+	// ObservePhaseDuration は、フェーズ全体の所要時間を記録します。
+	ObservePhaseDuration(phase string, d time.Duration)
+	// FeedFetchFailed は、フィード・サイトマップの取得・解析の失敗を記録します。
+	FeedFetchFailed(kind, feedURL string)
+}
+
+// nopMetrics は、メトリクスを記録しない MetricsRecorder です。
+type nopMetrics struct{}
+
+func (nopMetrics) ArticleAttempted(string, string)            {}
+func (nopMetrics) ArticleSucceeded(string, string)            {}
+func (nopMetrics) ArticleFailed(string, string)               {}
+func (nopMetrics) RetrySucceeded(string, int)                 {}
+func (nopMetrics) ObservePhaseDuration(string, time.Duration) {}
+func (nopMetrics) FeedFetchFailed(string, string)             {}
+
+// metricsOrNop は、recorder が nil の場合にメトリクスを記録しない実装を返します。
+func metricsOrNop(recorder MetricsRecorder) MetricsRecorder {
+	if recorder == nil {
+		return nopMetrics{}
+	}
+	return recorder
+}
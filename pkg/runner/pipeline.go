@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/shouni/web-text-pipe-go/pkg/pipeline"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ResultPhasePipeline は、抽出後の後処理のステージで除外された、または失敗した結果を表します。
+const ResultPhasePipeline = "pipeline"
+
+// RecordProcessor は、抽出後の記事の後処理の抽象化です (pipeline.Pipeline が実装します)。
+// 記事を除外する場合は pipeline.ErrDropped をラップしたエラーを返します。
+type RecordProcessor interface {
+	Process(ctx context.Context, record pipeline.Record) (pipeline.Record, error)
+}
+
+// ApplyPipeline は、抽出に成功した記事に後処理を適用し、result.Results と result.TitlesMap を更新します。
+// 除外された記事と後処理に失敗した記事は、本文を空にしてエラー (フェーズ: ResultPhasePipeline) を設定します。
+// 除外された記事のエラーは pipeline.ErrDropped をラップするため、errors.Is で後処理の失敗と区別できます。
+// Runner と StreamResults は、結果が確定するたびに同じ後処理を適用します。
+// ScraperExecutor の結果に後から適用する場合は、この関数を使用します。
+func ApplyPipeline(ctx context.Context, processor RecordProcessor, result *RunnerResult) {
+	if processor == nil {
+		return
+	}
+	ctx, span := tracer.Start(ctx, "Runner.pipeline")
+	defer span.End()
+
+	var stats pipelineStats
+	for i := range result.Results {
+		result.Results[i] = applyPipeline(ctx, processor, result, result.Results[i])
+		stats.add(result.Results[i])
+	}
+	stats.log(span)
+}
+
+// applyPipeline は、抽出に成功した1件の結果に後処理を適用した結果を返し、記事タイトルの変更を result.TitlesMap に反映します。
+func applyPipeline(ctx context.Context, processor RecordProcessor, result *RunnerResult, res ScrapeResult) ScrapeResult {
+	if res.Error != nil {
+		return res
+	}
+
+	out, err := processor.Process(ctx, pipeline.Record{
+		URL:      res.URL,
+		Title:    result.TitlesMap[res.URL],
+		Feeds:    result.SourcesMap[res.URL],
+		Content:  res.Content,
+		Charset:  res.Charset,
+		Metadata: res.Metadata,
+		Item:     result.FeedItem(res.URL),
+		Page:     res.Page,
+	})
+	if err != nil {
+		res.Content = ""
+		res.Error = err
+		res.Phase = ResultPhasePipeline
+		if !errors.Is(err, pipeline.ErrDropped) {
+			slog.Error("記事の後処理に失敗しました", slog.String("url", res.URL), slog.Any("error", err))
+		}
+		return res
+	}
+
+	res.Content = out.Content
+	res.Metadata = out.Metadata
+	if out.Title != result.TitlesMap[res.URL] {
+		if result.TitlesMap == nil {
+			result.TitlesMap = make(map[string]string)
+		}
+		result.TitlesMap[res.URL] = out.Title
+	}
+	return res
+}
+
+// pipelineStats は、後処理で除外・失敗した記事数の集計です。
+type pipelineStats struct {
+	dropped, failed int
+}
+
+func (s *pipelineStats) add(res ScrapeResult) {
+	if res.Phase != ResultPhasePipeline {
+		return
+	}
+	if errors.Is(res.Error, pipeline.ErrDropped) {
+		s.dropped++
+	} else {
+		s.failed++
+	}
+}
+
+// log は、集計結果をスパンの属性とログに記録します。
+func (s *pipelineStats) log(span trace.Span) {
+	span.SetAttributes(
+		attribute.Int("pipeline.dropped", s.dropped),
+		attribute.Int("pipeline.failed", s.failed),
+	)
+	slog.Info("記事の後処理が完了しました", slog.Int("dropped", s.dropped), slog.Int("failed", s.failed))
+}
@@ -0,0 +1,46 @@
+package runner
+
+import "context"
+
+// ----------------------------------------------------------------
+// スクレイピングの進捗通知
+// ----------------------------------------------------------------
+
+// Progress は、ScraperExecutor によるスクレイピングの進捗です。
+type Progress struct {
+	Total   int    `json:"total"`           // 処理対象のURL数
+	Done    int    `json:"done"`            // 抽出に成功したURL数
+	Failed  int    `json:"failed"`          // 最終的に抽出に失敗したURL数
+	Pending int    `json:"pending"`         // 未処理またはリトライ待ちのURL数
+	Phase   string `json:"phase,omitempty"` // 現在のフェーズ (ResultPhaseParallel / ResultPhaseRetry)
+}
+
+// ProgressFunc は、進捗が更新されるたびに呼び出されるコールバックです。
+type ProgressFunc func(Progress)
+
+type progressKey struct{}
+
+// WithProgress は、進捗の通知先を設定したコンテキストを返します。
+// ScraperExecutor のシグネチャを変えずに、呼び出しごとに異なる通知先を渡すために使用します。
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// reportProgress は、コンテキストに通知先が設定されている場合に進捗を通知します。
+func reportProgress(ctx context.Context, progress Progress) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(progress)
+	}
+}
+
+// newProgress は、総数と未成功のURL数から Progress を生成します。
+// finished が true の場合、未成功のURLは最終的な失敗として数えます。
+func newProgress(total, unsuccessful int, finished bool, phase string) Progress {
+	progress := Progress{Total: total, Done: total - unsuccessful, Phase: phase}
+	if finished {
+		progress.Failed = unsuccessful
+	} else {
+		progress.Pending = unsuccessful
+	}
+	return progress
+}
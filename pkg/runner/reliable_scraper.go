@@ -7,8 +7,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/shouni/web-text-pipe-go/pkg/article"
+	"github.com/shouni/web-text-pipe-go/pkg/charset"
+
 	"github.com/shouni/go-web-exact/v2/pkg/scraper"
 	"github.com/shouni/go-web-exact/v2/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ----------------------------------------------------------------
@@ -16,9 +21,13 @@ import (
 // ----------------------------------------------------------------
 
 const (
-	InitialScrapeDelay = 5 * time.Second
-	RetryScrapeDelay   = 3 * time.Second
-	PhaseContent       = "ContentExtraction"
+	PhaseContent = "ContentExtraction"
+
+	// ResultPhaseParallel と ResultPhaseRetry は、最終結果を生成した処理フェーズを表します。
+	ResultPhaseParallel = "parallel"
+	ResultPhaseRetry    = "retry"
+	// ResultPhaseRobots は、robots.txt で取得が許可されていないため抽出せずにスキップした結果を表します。
+	ResultPhaseRobots = "robots"
 )
 
 // Extractor はコンテンツ抽出ロジックの抽象化です。リトライ時の単体抽出に使用します。
@@ -26,107 +35,392 @@ type Extractor interface {
 	FetchAndExtractText(ctx context.Context, url string) (string, bool, error)
 }
 
+// ScrapeResult は types.URLResult に、ReliableScraper による処理の経緯を付加した結果です。
+// 失敗したURLも最終エラーとともに保持されます。
+type ScrapeResult struct {
+	types.URLResult
+	Attempts int    // 抽出を試行した回数 (初回の並列抽出を含む)
+	Phase    string // 最終結果を生成したフェーズ (ResultPhaseParallel / ResultPhaseRetry / ResultPhaseRobots / ResultPhasePipeline)
+	Charset  string // 取得したページについて判定した文字コード (charset.NewFetcher を使用した場合のみ)
+
+	Page *article.Page // 取得したページのメタデータ (article.NewFetcher を使用した場合のみ。見つからない場合は nil)
+
+	Metadata map[string]string // 後処理のステージが付加したメタデータ (ApplyPipeline を参照)
+}
+
 // ReliableScraper は ScraperExecutor インターフェースを実装し、
 // リトライと遅延のロジックを重ねて信頼性を高めます。
 type ReliableScraper struct {
 	baseScraper scraper.Scraper // scraper.ParallelScraper のインターフェース
 	extractor   Extractor       // extract.Extractor のインターフェース
+	retryPolicy RetryPolicy     // 失敗URLに対するリトライ戦略
+
+	Metrics MetricsRecorder // メトリクスの記録先 (nil の場合は記録しない)
+	Robots  RobotsChecker   // robots.txt による判定 (nil の場合は判定しない)
+
+	Normalizer TextNormalizer // 抽出後の本文の正規化 (nil の場合は正規化しない)
+}
+
+// TextNormalizer は、抽出後の本文の正規化の抽象化です (normalize.Normalizer が実装します)。
+type TextNormalizer interface {
+	Normalize(text string) string
 }
 
 // NewReliableScraper は ReliableScraper の新しいインスタンスを作成します。
-func NewReliableScraper(baseScraper scraper.Scraper, extractor Extractor) *ReliableScraper {
+func NewReliableScraper(baseScraper scraper.Scraper, extractor Extractor, retryPolicy RetryPolicy) *ReliableScraper {
 	return &ReliableScraper{
 		baseScraper: baseScraper,
 		extractor:   extractor,
+		retryPolicy: retryPolicy,
 	}
 }
 
+// StreamingScraper は、抽出が完了したURLから順に結果を送信する scraper.Scraper です (hostlimit.Scraper が実装します)。
+// ReliableScraper のベースのスクレイパーがこのインターフェースを実装していない場合は、
+// 並列抽出がすべて完了してから結果を送信します。
+type StreamingScraper interface {
+	ScrapeStream(ctx context.Context, urls []string) <-chan types.URLResult
+}
+
 // ScrapeInParallel は、URLリストに対して並列スクレイピングと、失敗したURLに対するリトライを実行します。
-func (r *ReliableScraper) ScrapeInParallel(ctx context.Context, urls []string) []types.URLResult {
+// 戻り値は入力URLの順序で、成功・失敗を問わずすべてのURLの最終結果を含みます。
+// 処理の内容は ScrapeStream と同じで、すべての結果が確定してから返します。
+func (r *ReliableScraper) ScrapeInParallel(ctx context.Context, urls []string) []ScrapeResult {
+	finalResults := make(map[string]*ScrapeResult, len(urls))
+	for res := range r.ScrapeStream(ctx, urls) {
+		finalResults[res.URL] = &res
+	}
+	return orderedResults(urls, finalResults)
+}
+
+// ScrapeStream は、URLリストに対して並列スクレイピングと、失敗したURLに対するリトライを実行し、
+// 最終結果が確定したURLから順に結果を送信するチャネルを返します。
+// 並列抽出に成功したURLは完了次第、失敗したURLはリトライで成功した時点、またはリトライを終えた時点で送信します。
+// チャネルは、成功・失敗を問わずすべてのURL (重複を除く) の最終結果を送信した後に閉じられます。
+// 呼び出し元はチャネルが閉じられるまで受信してください。
+//
+// コンテキストがキャンセルされた場合は待機とリトライを打ち切り、未処理のURLの結果には
+// キャンセル理由をエラーとして設定します。
+// コンテキストに WithProgress で通知先が設定されている場合は、各フェーズの進捗を通知します。
+// Robots が設定されている場合、robots.txt で取得が許可されていないURLは抽出せず、
+// ErrDisallowedByRobots をエラーとする結果 (フェーズ: ResultPhaseRobots) を送信します。
+func (r *ReliableScraper) ScrapeStream(ctx context.Context, urls []string) <-chan ScrapeResult {
+	results := make(chan ScrapeResult)
+	go func() {
+		defer close(results)
+		r.scrape(ctx, uniqueURLs(urls), func(res ScrapeResult) { results <- res })
+	}()
+	return results
+}
+
+// scrape は ScrapeStream の処理本体です。最終結果が確定するたびに emit を呼び出します。
+func (r *ReliableScraper) scrape(ctx context.Context, urls []string, emit func(ScrapeResult)) {
+	ctx, span := tracer.Start(ctx, "ReliableScraper.ScrapeInParallel", trace.WithAttributes(attribute.Int("scraper.url_count", len(urls))))
+	defer span.End()
+
+	// 取得したページの文字コードとメタデータは、charset.NewFetcher・article.NewFetcher がコンテキストの Recorder に記録する
+	charsets := charset.NewRecorder()
+	ctx = charset.WithRecorder(ctx, charsets)
+	pages := article.NewRecorder()
+	ctx = article.WithRecorder(ctx, pages)
+	emitFinal := func(result *ScrapeResult) {
+		emit(r.finalizeResult(*result, charsets, pages))
+	}
+
+	// 0. robots.txt による除外
+	scrapeURLs := urls
+	var disallowedURLs []string
+	if r.Robots != nil {
+		scrapeURLs, disallowedURLs = filterRobots(ctx, r.Robots, urls)
+		span.SetAttributes(attribute.Int("scraper.disallowed_by_robots", len(disallowedURLs)))
+		if len(disallowedURLs) > 0 {
+			slog.Warn("robots.txt により取得が許可されていない記事をスキップします。", slog.Int("count", len(disallowedURLs)))
+		}
+	}
+	for _, url := range disallowedURLs {
+		emitFinal(disallowedResult(url))
+	}
+
 	slog.Info("フェーズ1 - Webコンテンツの並列抽出を開始します。")
 
-	// 1. 初回並列実行
-	results := r.baseScraper.ScrapeInParallel(ctx, urls)
+	// 1. 初回並列実行 (成功した結果は完了次第送信し、失敗した結果はリトライのために保持する)
+	// 個々のURLの取得は、builder で計測用にラップされた Fetcher が子スパンとして記録する
+	metrics := metricsOrNop(r.Metrics)
+	totalCount := len(scrapeURLs)
+	reportProgress(ctx, newProgress(totalCount, totalCount, false, ResultPhaseParallel))
+	parallelCtx, parallelSpan := tracer.Start(ctx, "ReliableScraper.parallel")
+	parallelStartedAt := time.Now()
 
-	// 2. 無条件遅延 (負荷軽減)
-	slog.Info("並列抽出が完了しました。次の処理に進む前に待機します。", slog.String("phase", PhaseContent), slog.Duration("delay", InitialScrapeDelay))
-	time.Sleep(InitialScrapeDelay)
+	failedResults := make(map[string]*ScrapeResult)
+	received := make(map[string]bool, totalCount)
+	initialSuccessfulCount := 0
+	for res := range r.parallelStream(parallelCtx, scrapeURLs) {
+		if received[res.URL] {
+			continue
+		}
+		received[res.URL] = true
+
+		result := parallelResult(res)
+		metrics.ArticleAttempted(result.URL, ResultPhaseParallel)
+		if result.Error == nil {
+			metrics.ArticleSucceeded(result.URL, ResultPhaseParallel)
+			initialSuccessfulCount++
+			emitFinal(result)
+		} else {
+			metrics.ArticleFailed(result.URL, ResultPhaseParallel)
+			failedResults[result.URL] = result
+		}
+		reportProgress(ctx, newProgress(totalCount, totalCount-initialSuccessfulCount, false, ResultPhaseParallel))
+	}
+	metrics.ObservePhaseDuration(ResultPhaseParallel, time.Since(parallelStartedAt))
+
+	// 2. 並列抽出の結果に含まれないURLを失敗として扱い、失敗URLを入力の順序で並べる
+	var failedURLs []string
+	for _, url := range scrapeURLs {
+		if !received[url] {
+			failedResults[url] = &ScrapeResult{
+				URLResult: types.URLResult{URL: url, Error: fmt.Errorf("URL %s の並列抽出結果が返されませんでした", url)},
+				Attempts:  1,
+				Phase:     ResultPhaseParallel,
+			}
+			metrics.ArticleAttempted(url, ResultPhaseParallel)
+			metrics.ArticleFailed(url, ResultPhaseParallel)
+		}
+		if _, failed := failedResults[url]; failed {
+			failedURLs = append(failedURLs, url)
+		}
+	}
+	// 未送信の失敗URLの結果は、リトライの成否にかかわらず最後に送信する
+	defer func() {
+		for _, url := range failedURLs {
+			if result, ok := failedResults[url]; ok {
+				emitFinal(result)
+			}
+		}
+	}()
+	reportProgress(ctx, newProgress(totalCount, len(failedURLs), false, ResultPhaseParallel))
+	parallelSpan.SetAttributes(
+		attribute.Int("scraper.successful", initialSuccessfulCount),
+		attribute.Int("scraper.failed", len(failedURLs)),
+	)
+	parallelSpan.End()
 
-	// 3. 結果の分類
-	successfulResults, failedURLs := classifyResults(results)
-	initialSuccessfulCount := len(successfulResults)
+	// 3. 無条件遅延 (負荷軽減)
+	slog.Info("並列抽出が完了しました。次の処理に進む前に待機します。", slog.String("phase", PhaseContent), slog.Duration("delay", r.retryPolicy.InitialDelay))
+	_, delaySpan := tracer.Start(ctx, "ReliableScraper.initialDelay", trace.WithAttributes(attribute.String("scraper.delay", r.retryPolicy.InitialDelay.String())))
+	err := waitContext(ctx, r.retryPolicy.InitialDelay)
+	endSpan(delaySpan, err)
+	if err != nil {
+		slog.Warn("待機中に処理が中断されました。リトライを行わずに終了します。", slog.Any("cause", err), slog.Int("pending", len(failedURLs)))
+		markInterrupted(failedResults, failedURLs, err)
+		reportProgress(ctx, newProgress(totalCount, len(failedURLs), true, ResultPhaseParallel))
+		return
+	}
 
 	// 4. 失敗URLの上位レベルリトライ処理 (Extractor を使用した順次リトライ)
-	if len(failedURLs) > 0 {
-		retriedSuccessfulResults, retryErr := r.processFailedURLs(ctx, failedURLs, RetryScrapeDelay)
+	pendingURLs := failedURLs
+	finalPhase := ResultPhaseParallel
+	if len(failedURLs) > 0 && r.retryPolicy.MaxAttempts > 0 {
+		var retryErr error
+		finalPhase = ResultPhaseRetry
+		retryCtx, retrySpan := tracer.Start(ctx, "ReliableScraper.retry", trace.WithAttributes(attribute.Int("scraper.failed", len(failedURLs))))
+		retryStartedAt := time.Now()
+		pendingURLs, retryErr = r.processFailedURLs(retryCtx, totalCount, failedURLs, failedResults, emitFinal)
+		metrics.ObservePhaseDuration(ResultPhaseRetry, time.Since(retryStartedAt))
+		retrySpan.SetAttributes(attribute.Int("scraper.still_failed", len(pendingURLs)))
+		endSpan(retrySpan, retryErr)
 		if retryErr != nil {
-			slog.Warn("失敗URLのリトライ処理中にエラーが発生しました", slog.Any("error", retryErr))
+			slog.Warn("失敗URLのリトライ処理が中断されました", slog.Any("cause", retryErr), slog.Int("pending", len(pendingURLs)))
+			markInterrupted(failedResults, pendingURLs, retryErr)
 		}
-		successfulResults = append(successfulResults, retriedSuccessfulResults...)
 	}
 
 	// 5. 最終チェックとログ
-	if len(successfulResults) == 0 {
+	successfulCount := totalCount - len(pendingURLs)
+	reportProgress(ctx, newProgress(totalCount, len(pendingURLs), true, finalPhase))
+	span.SetAttributes(
+		attribute.Int("scraper.successful", successfulCount),
+		attribute.Int("scraper.failed", len(pendingURLs)),
+	)
+	if successfulCount == 0 {
 		slog.Error("処理可能なWebコンテンツを一件も取得できませんでした。URLを確認してください。")
-		return []types.URLResult{}
 	}
 
 	slog.Info("コンテンツ取得結果",
-		slog.Int("successful", len(successfulResults)),
-		slog.Int("total", len(urls)),
+		slog.Int("successful", successfulCount),
+		slog.Int("failed", len(pendingURLs)),
+		slog.Int("total", totalCount),
 		slog.Int("initial_successful", initialSuccessfulCount),
-		slog.Int("retry_successful", len(successfulResults)-initialSuccessfulCount),
+		slog.Int("retry_successful", successfulCount-initialSuccessfulCount),
+		slog.Int("disallowed_by_robots", len(disallowedURLs)),
 		slog.String("phase", PhaseContent),
 	)
+}
 
-	return successfulResults
+// parallelStream は、ベースのスクレイパーによる並列抽出の結果を完了順に送信するチャネルを返します。
+// ベースのスクレイパーが StreamingScraper を実装していない場合は、すべての抽出が完了してから送信します。
+func (r *ReliableScraper) parallelStream(ctx context.Context, urls []string) <-chan types.URLResult {
+	if streaming, ok := r.baseScraper.(StreamingScraper); ok {
+		return streaming.ScrapeStream(ctx, urls)
+	}
+	results := r.baseScraper.ScrapeInParallel(ctx, urls)
+	resultsChan := make(chan types.URLResult, len(results))
+	for _, res := range results {
+		resultsChan <- res
+	}
+	close(resultsChan)
+	return resultsChan
 }
 
-// processFailedURLsは、失敗したURLに対し、指定された遅延時間後に順次リトライを実行します。
-func (r *ReliableScraper) processFailedURLs(ctx context.Context, failedURLs []string, retryDelay time.Duration) ([]types.URLResult, error) {
-	slog.Warn("抽出に失敗したURLがありました。待機後、順次リトライを開始します。", slog.Int("count", len(failedURLs)), slog.Duration("delay", retryDelay))
-	time.Sleep(retryDelay)
+// processFailedURLsは、失敗したURLに対し、リトライポリシーに従って待機と順次リトライを繰り返し、
+// failedResults の該当エントリを最新の結果で更新します。各試行で失敗したURLのみが次の試行の対象となります。
+// リトライで成功したURLは、その時点で emit に渡して failedResults から削除します。
+// 戻り値は最終的に成功しなかったURLです。コンテキストがキャンセルされた場合は直ちに中断し、
+// 未処理のURLとキャンセル理由を返します。total は進捗の通知に使用する全URL数です。
+func (r *ReliableScraper) processFailedURLs(ctx context.Context, total int, failedURLs []string, failedResults map[string]*ScrapeResult, emit func(*ScrapeResult)) ([]string, error) {
+	pendingURLs := failedURLs
+	metrics := metricsOrNop(r.Metrics)
 
-	var retriedSuccessfulResults []types.URLResult
-	slog.Info("失敗URLの順次リトライを開始します。")
+	for attempt := 1; attempt <= r.retryPolicy.MaxAttempts && len(pendingURLs) > 0; attempt++ {
+		retryDelay := r.retryPolicy.Delay(attempt)
+		slog.Warn("抽出に失敗したURLがありました。待機後、順次リトライを開始します。",
+			slog.Int("count", len(pendingURLs)),
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", r.retryPolicy.MaxAttempts),
+			slog.Duration("delay", retryDelay),
+		)
+		_, waitSpan := tracer.Start(ctx, "ReliableScraper.retryWait", trace.WithAttributes(
+			attribute.Int("retry.attempt", attempt),
+			attribute.String("retry.delay", retryDelay.String()),
+		))
+		err := waitContext(ctx, retryDelay)
+		endSpan(waitSpan, err)
+		if err != nil {
+			return pendingURLs, err
+		}
 
-	for _, url := range failedURLs {
-		slog.Info("リトライ中", slog.String("url", url))
+		slog.Info("失敗URLの順次リトライを開始します。", slog.Int("attempt", attempt))
 
-		content, hasBodyFound, err := r.extractor.FetchAndExtractText(ctx, url)
+		var stillFailedURLs []string
+		for i, url := range pendingURLs {
+			if err := context.Cause(ctx); err != nil {
+				// 未試行のURLと、この試行で失敗済みのURLを未処理として返す
+				return append(stillFailedURLs, pendingURLs[i:]...), err
+			}
 
-		var extractErr error
-		if err != nil {
-			extractErr = fmt.Errorf("コンテンツの抽出に失敗しました: %w", err)
-		} else if content == "" || !hasBodyFound {
-			extractErr = fmt.Errorf("URL %s から有効な本文を抽出できませんでした", url)
+			slog.Info("リトライ中", slog.String("url", url), slog.Int("attempt", attempt))
+
+			extractCtx, extractSpan := tracer.Start(ctx, "ReliableScraper.retryExtract", trace.WithAttributes(
+				attribute.String("url.full", url),
+				attribute.Int("retry.attempt", attempt),
+			))
+			content, hasBodyFound, err := r.extractor.FetchAndExtractText(extractCtx, url)
+
+			var extractErr error
+			if err != nil {
+				extractErr = fmt.Errorf("コンテンツの抽出に失敗しました: %w", err)
+			} else if content == "" || !hasBodyFound {
+				extractErr = fmt.Errorf("URL %s から有効な本文を抽出できませんでした", url)
+			}
+			endSpan(extractSpan, extractErr)
+
+			result := failedResults[url]
+			result.Attempts++
+			result.Phase = ResultPhaseRetry
+			metrics.ArticleAttempted(url, ResultPhaseRetry)
+
+			if extractErr != nil {
+				formattedErr := formatErrorLog(extractErr)
+				slog.Error("リトライでもURLの抽出に失敗しました", slog.String("url", url), slog.Int("attempt", attempt), slog.String("error", formattedErr))
+				result.Content = ""
+				result.Error = extractErr
+				stillFailedURLs = append(stillFailedURLs, url)
+				metrics.ArticleFailed(url, ResultPhaseRetry)
+			} else {
+				slog.Info("URLの抽出がリトライで成功しました", slog.String("url", url), slog.Int("attempt", attempt))
+				result.Content = content
+				result.Error = nil
+				metrics.ArticleSucceeded(url, ResultPhaseRetry)
+				metrics.RetrySucceeded(url, attempt)
+				delete(failedResults, url)
+				emit(result)
+			}
+			reportProgress(ctx, newProgress(total, len(stillFailedURLs)+len(pendingURLs)-i-1, false, ResultPhaseRetry))
 		}
+		pendingURLs = stillFailedURLs
+	}
+	return pendingURLs, nil
+}
 
-		if extractErr != nil {
-			formattedErr := formatErrorLog(extractErr)
-			slog.Error("リトライでもURLの抽出に失敗しました", slog.String("url", url), slog.String("error", formattedErr))
-		} else {
-			slog.Info("URLの抽出がリトライで成功しました", slog.String("url", url))
-			retriedSuccessfulResults = append(retriedSuccessfulResults, types.URLResult{
-				URL:     url,
-				Content: content,
-				Error:   nil,
-			})
+// markInterruptedは、処理の中断により抽出を完了できなかったURLの結果に、中断理由をエラーとして設定します。
+// エラーは cause をラップするため、errors.Is(err, context.DeadlineExceeded) などで判別できます。
+func markInterrupted(failedResults map[string]*ScrapeResult, urls []string, cause error) {
+	for _, url := range urls {
+		result := failedResults[url]
+		result.Content = ""
+		result.Error = fmt.Errorf("処理が中断されたため抽出を完了できませんでした (直前のエラー: %v): %w", result.Error, cause)
+	}
+}
+
+// disallowedResultは、robots.txt で取得が許可されていないURLの結果を返します。
+func disallowedResult(url string) *ScrapeResult {
+	return &ScrapeResult{
+		URLResult: types.URLResult{URL: url, Error: ErrDisallowedByRobots},
+		Phase:     ResultPhaseRobots,
+	}
+}
+
+// parallelResultは、並列抽出の結果を ScrapeResult に変換します。本文が空の結果はエラーを持つ失敗結果として扱います。
+func parallelResult(res types.URLResult) *ScrapeResult {
+	if res.Error == nil && res.Content == "" {
+		res.Error = fmt.Errorf("URL %s から有効な本文を抽出できませんでした", res.URL)
+	}
+	return &ScrapeResult{
+		URLResult: res,
+		Attempts:  1,
+		Phase:     ResultPhaseParallel,
+	}
+}
+
+// uniqueURLsは、順序を保ったまま重複したURLを取り除きます。
+func uniqueURLs(urls []string) []string {
+	seen := make(map[string]bool, len(urls))
+	unique := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if seen[url] {
+			continue
 		}
+		seen[url] = true
+		unique = append(unique, url)
 	}
-	return retriedSuccessfulResults, nil
+	return unique
 }
 
-// classifyResultsは並列抽出の結果を成功と失敗に分類します。
-func classifyResults(results []types.URLResult) (successfulResults []types.URLResult, failedURLs []string) {
-	for _, res := range results {
-		if res.Error != nil || res.Content == "" {
-			failedURLs = append(failedURLs, res.URL)
-		} else {
-			successfulResults = append(successfulResults, res)
+// orderedResultsは、最終結果を入力URLの順序 (重複を除く) で並べたスライスを返します。
+// 結果のないURLは含めません。
+func orderedResults(urls []string, finalResults map[string]*ScrapeResult) []ScrapeResult {
+	ordered := make([]ScrapeResult, 0, len(finalResults))
+	seen := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		result, ok := finalResults[url]
+		if seen[url] || !ok {
+			continue
 		}
+		seen[url] = true
+		ordered = append(ordered, *result)
+	}
+	return ordered
+}
+
+// finalizeResultは、取得したページについて判定した文字コードとページのメタデータを結果に設定し、
+// Normalizer が設定されている場合は、成功した結果の本文を正規化します。
+func (r *ReliableScraper) finalizeResult(result ScrapeResult, charsets *charset.Recorder, pages *article.Recorder) ScrapeResult {
+	result.Charset = charsets.Charset(result.URL)
+	result.Page = pages.Page(result.URL)
+	if r.Normalizer != nil && result.Error == nil {
+		result.Content = r.Normalizer.Normalize(result.Content)
 	}
-	return successfulResults, failedURLs
+	return result
 }
 
 // formatErrorLogは、冗長なエラーメッセージを短縮します。
@@ -0,0 +1,192 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// fakeScraper は、URLごとに設定した結果を返す並列抽出のスクレイパーです。
+// results に含まれないURLは結果を返しません。
+type fakeScraper struct {
+	results map[string]types.URLResult
+}
+
+func (s *fakeScraper) ScrapeInParallel(ctx context.Context, urls []string) []types.URLResult {
+	var results []types.URLResult
+	for _, url := range urls {
+		if res, ok := s.results[url]; ok {
+			results = append(results, res)
+		}
+	}
+	return results
+}
+
+// fakeExtractor は、リトライ時の単体抽出で、URLごとに設定した回数だけ失敗してから成功する Extractor です。
+// failures が負のURLは常に失敗します。
+type fakeExtractor struct {
+	failures map[string]int
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (e *fakeExtractor) FetchAndExtractText(ctx context.Context, url string) (string, bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.calls == nil {
+		e.calls = make(map[string]int)
+	}
+	e.calls[url]++
+	if n := e.failures[url]; n < 0 || e.calls[url] <= n {
+		return "", false, errors.New("抽出エラー")
+	}
+	return "リトライの本文: " + url, true, nil
+}
+
+func TestReliableScraperResults(t *testing.T) {
+	base := &fakeScraper{results: map[string]types.URLResult{
+		"https://example.com/ok":     {URL: "https://example.com/ok", Content: "本文"},
+		"https://example.com/retry":  {URL: "https://example.com/retry", Error: errors.New("並列抽出エラー")},
+		"https://example.com/failed": {URL: "https://example.com/failed", Error: errors.New("並列抽出エラー")},
+		"https://example.com/empty":  {URL: "https://example.com/empty"},
+	}}
+	extractor := &fakeExtractor{failures: map[string]int{
+		"https://example.com/retry":  1,
+		"https://example.com/failed": -1,
+	}}
+	s := NewReliableScraper(base, extractor, RetryPolicy{MaxAttempts: 2})
+
+	urls := []string{
+		"https://example.com/ok",
+		"https://example.com/retry",
+		"https://example.com/failed",
+		"https://example.com/missing",
+		"https://example.com/ok",
+		"https://example.com/empty",
+	}
+	results := s.ScrapeInParallel(context.Background(), urls)
+
+	want := []struct {
+		url      string
+		content  string
+		wantErr  bool
+		attempts int
+		phase    string
+	}{
+		{"https://example.com/ok", "本文", false, 1, ResultPhaseParallel},
+		{"https://example.com/retry", "リトライの本文: https://example.com/retry", false, 3, ResultPhaseRetry},
+		{"https://example.com/failed", "", true, 3, ResultPhaseRetry},
+		{"https://example.com/missing", "リトライの本文: https://example.com/missing", false, 2, ResultPhaseRetry},
+		{"https://example.com/empty", "リトライの本文: https://example.com/empty", false, 2, ResultPhaseRetry},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.URL != w.url {
+			t.Errorf("results[%d].URL = %q, want %q", i, got.URL, w.url)
+			continue
+		}
+		if got.Content != w.content {
+			t.Errorf("%s: Content = %q, want %q", w.url, got.Content, w.content)
+		}
+		if (got.Error != nil) != w.wantErr {
+			t.Errorf("%s: Error = %v, wantErr %v", w.url, got.Error, w.wantErr)
+		}
+		if got.Attempts != w.attempts {
+			t.Errorf("%s: Attempts = %d, want %d", w.url, got.Attempts, w.attempts)
+		}
+		if got.Phase != w.phase {
+			t.Errorf("%s: Phase = %q, want %q", w.url, got.Phase, w.phase)
+		}
+	}
+}
+
+func TestReliableScraperCancel(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+	}{
+		{"並列抽出後の待機中", RetryPolicy{MaxAttempts: 3, InitialDelay: time.Hour}},
+		{"リトライ前の待機中", RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &fakeScraper{results: map[string]types.URLResult{
+				"https://example.com/ok":     {URL: "https://example.com/ok", Content: "本文"},
+				"https://example.com/failed": {URL: "https://example.com/failed", Error: errors.New("並列抽出エラー")},
+			}}
+			extractor := &fakeExtractor{}
+			s := NewReliableScraper(base, extractor, tt.policy)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			timer := time.AfterFunc(50*time.Millisecond, cancel)
+			defer timer.Stop()
+
+			start := time.Now()
+			results := s.ScrapeInParallel(ctx, []string{"https://example.com/ok", "https://example.com/failed", "https://example.com/missing"})
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("ScrapeInParallel() returned after %s, want prompt return on cancel", elapsed)
+			}
+
+			if len(results) != 3 {
+				t.Fatalf("len(results) = %d, want 3", len(results))
+			}
+			if results[0].Error != nil {
+				t.Errorf("%s: Error = %v, want nil", results[0].URL, results[0].Error)
+			}
+			for _, res := range results[1:] {
+				if !errors.Is(res.Error, context.Canceled) {
+					t.Errorf("%s: Error = %v, want context.Canceled", res.URL, res.Error)
+				}
+				if res.Attempts != 1 || res.Phase != ResultPhaseParallel {
+					t.Errorf("%s: Attempts = %d, Phase = %q, want 1, %q", res.URL, res.Attempts, res.Phase, ResultPhaseParallel)
+				}
+			}
+			if len(extractor.calls) != 0 {
+				t.Errorf("extractor calls = %v, want none", extractor.calls)
+			}
+		})
+	}
+}
+
+func TestReliableScraperMaxAttempts(t *testing.T) {
+	tests := []struct {
+		maxAttempts int
+		wantPhase   string
+	}{
+		{0, ResultPhaseParallel},
+		{1, ResultPhaseRetry},
+		{3, ResultPhaseRetry},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("MaxAttempts=%d", tt.maxAttempts), func(t *testing.T) {
+			const url = "https://example.com/failed"
+			base := &fakeScraper{results: map[string]types.URLResult{
+				url: {URL: url, Error: errors.New("並列抽出エラー")},
+			}}
+			extractor := &fakeExtractor{failures: map[string]int{url: -1}}
+			s := NewReliableScraper(base, extractor, RetryPolicy{MaxAttempts: tt.maxAttempts})
+
+			results := s.ScrapeInParallel(context.Background(), []string{url})
+			if len(results) != 1 {
+				t.Fatalf("len(results) = %d, want 1", len(results))
+			}
+			if got := extractor.calls[url]; got != tt.maxAttempts {
+				t.Errorf("retry calls = %d, want %d", got, tt.maxAttempts)
+			}
+			if got := results[0]; got.Error == nil || got.Attempts != tt.maxAttempts+1 || got.Phase != tt.wantPhase {
+				t.Errorf("result = {Error: %v, Attempts: %d, Phase: %q}, want error, %d, %q", got.Error, got.Attempts, got.Phase, tt.maxAttempts+1, tt.wantPhase)
+			}
+		})
+	}
+}
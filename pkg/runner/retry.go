@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// ----------------------------------------------------------------
+// リトライポリシー (RetryPolicy) - ワークフロー層のリトライ設定
+// ----------------------------------------------------------------
+
+const (
+	DefaultMaxAttempts  = 1
+	DefaultInitialDelay = 5 * time.Second
+	DefaultBaseDelay    = 3 * time.Second
+	DefaultMaxDelay     = 30 * time.Second
+	DefaultJitter       = 0.2
+)
+
+// RetryPolicy は ReliableScraper が失敗URLを再試行する際の戦略を保持します。
+type RetryPolicy struct {
+	MaxAttempts  int           // 失敗URLに対するリトライの最大回数 (0 の場合はリトライしない)
+	InitialDelay time.Duration // 並列抽出の完了後、次の処理に進む前の待機時間 (負荷軽減)
+	BaseDelay    time.Duration // 1回目のリトライ前の待機時間。以降は指数的に増加する
+	MaxDelay     time.Duration // リトライ前の待機時間の上限
+	Jitter       float64       // 待機時間に加える揺らぎの割合 (0.2 なら ±20%)
+}
+
+// DefaultRetryPolicy は、従来の固定値 (初回待機5秒、リトライ1回、リトライ前待機3秒) に相当するポリシーを返します。
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:  DefaultMaxAttempts,
+		InitialDelay: DefaultInitialDelay,
+		BaseDelay:    DefaultBaseDelay,
+		MaxDelay:     DefaultMaxDelay,
+		Jitter:       DefaultJitter,
+	}
+}
+
+// Delay は、attempt 回目 (1始まり) のリトライ前に待機する時間を返します。
+// BaseDelay を基準に指数バックオフし、ジッターを加えたうえで MaxDelay を上限とします。
+// MaxDelay が0の場合も、time.Duration の最大値を超えることはありません。
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 || p.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		// MaxDelay が未指定でも、2倍にするとオーバーフローする場合は最大値で打ち切る
+		if delay > math.MaxInt64/2 {
+			delay = math.MaxInt64
+			break
+		}
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			delay = p.MaxDelay
+			break
+		}
+	}
+
+	if p.Jitter > 0 {
+		// [-Jitter, +Jitter] の範囲で一様に揺らぎを加える
+		factor := 1 + p.Jitter*(2*rand.Float64()-1)
+		if jittered := float64(delay) * factor; jittered >= math.MaxInt64 {
+			delay = math.MaxInt64
+		} else {
+			delay = time.Duration(jittered)
+		}
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+// waitContext は、指定時間だけ待機します。待機中にコンテキストがキャンセルされた場合は、
+// 直ちにキャンセル理由 (context.Cause) を返します。
+func waitContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return context.Cause(ctx)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-timer.C:
+		return nil
+	}
+}
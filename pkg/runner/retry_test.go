@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"1回目は BaseDelay", RetryPolicy{BaseDelay: time.Second}, 1, time.Second},
+		{"指数バックオフ", RetryPolicy{BaseDelay: time.Second}, 4, 8 * time.Second},
+		{"MaxDelay を上限とする", RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, 4, 5 * time.Second},
+		{"MaxDelay が0でもオーバーフローしない", RetryPolicy{BaseDelay: time.Second}, 100, math.MaxInt64},
+		{"attempt が0の場合は待機しない", RetryPolicy{BaseDelay: time.Second}, 0, 0},
+		{"BaseDelay が0の場合は待機しない", RetryPolicy{}, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Delay(tt.attempt); got != tt.want {
+				t.Errorf("Delay(%d) = %s, want %s", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelayJitter(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 10 * time.Second, MaxDelay: 12 * time.Second, Jitter: 0.5}
+	for range 100 {
+		got := p.Delay(1)
+		if got < 5*time.Second || got > 12*time.Second {
+			t.Fatalf("Delay(1) = %s, want within [5s, 12s]", got)
+		}
+	}
+
+	// ジッターで最大値を超える場合もオーバーフローしない
+	p = RetryPolicy{BaseDelay: time.Second, Jitter: 1}
+	for range 100 {
+		if got := p.Delay(100); got <= 0 {
+			t.Fatalf("Delay(100) = %s, want > 0", got)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrDisallowedByRobots は、robots.txt で取得が許可されていないため記事をスキップしたことを表します。
+// スキップした記事の ScrapeResult.Error に設定されるため、errors.Is で通常の抽出失敗と区別できます。
+var ErrDisallowedByRobots = errors.New("robots.txt により取得が許可されていないためスキップしました")
+
+// robotsCheckConcurrency は、robots.txt による判定を並列に行う数です (ホストごとの初回は robots.txt を取得します)。
+const robotsCheckConcurrency = 8
+
+// RobotsChecker は、robots.txt に基づくアクセス可否の判定の抽象化です (robots.Checker が実装します)。
+type RobotsChecker interface {
+	Allowed(ctx context.Context, url string) bool
+}
+
+// filterRobots は、URLを robots.txt で取得が許可されているものとされていないものに分類します。
+// どちらも入力の順序を保持します。
+func filterRobots(ctx context.Context, checker RobotsChecker, urls []string) (allowed, disallowed []string) {
+	results := make([]bool, len(urls))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, robotsCheckConcurrency)
+	for i, url := range urls {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			results[i] = checker.Allowed(ctx, url)
+		}()
+	}
+	wg.Wait()
+
+	for i, url := range urls {
+		if results[i] {
+			allowed = append(allowed, url)
+		} else {
+			disallowed = append(disallowed, url)
+		}
+	}
+	return allowed, disallowed
+}
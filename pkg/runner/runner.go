@@ -2,13 +2,18 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/shouni/web-text-pipe-go/pkg/article"
+	"github.com/shouni/web-text-pipe-go/pkg/feedcache"
+
 	"github.com/mmcdole/gofeed"
-	"github.com/shouni/go-web-exact/v2/pkg/feed"
-	"github.com/shouni/go-web-exact/v2/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ----------------------------------------------------------------
@@ -23,7 +28,8 @@ type FeedParser interface {
 // ScraperExecutor はスクレイピングの実行機能を提供します。
 // ReliableScraper がこのインターフェースを実装します。
 type ScraperExecutor interface {
-	ScrapeInParallel(ctx context.Context, urls []string) []types.URLResult
+	// 戻り値は成功・失敗を問わず、すべての入力URLの最終結果を含みます。
+	ScrapeInParallel(ctx context.Context, urls []string) []ScrapeResult
 }
 
 // ----------------------------------------------------------------
@@ -32,68 +38,204 @@ type ScraperExecutor interface {
 
 // Runner は、フィードの取得、URLの抽出、スクレイピング実行という一連の処理フローを管理します。
 type Runner struct {
-	FeedParser      FeedParser
+	FeedSource      URLSource       // フィード (RSS/Atom) のURLソース
+	SitemapSource   URLSource       // サイトマップのURLソース (サイトマップを使用しない場合は nil)
 	ScraperExecutor ScraperExecutor // リトライ機能を持つ ReliableScraper が注入される
+	StateStore      StateStore      // 抽出済みURLの状態ストア (インクリメンタルスクレイピングを行わない場合は nil)
+	Pipeline        RecordProcessor // 抽出後の記事の後処理 (後処理を行わない場合は nil)
+	Metrics         MetricsRecorder // メトリクスの記録先 (nil の場合は記録しない)
 }
 
 // NewRunner は依存関係を注入して Runner を初期化する関数
 func NewRunner(parser FeedParser, scraperExecutor ScraperExecutor) *Runner {
 	return &Runner{
-		FeedParser:      parser,
+		FeedSource:      NewFeedSource(parser),
+		ScraperExecutor: scraperExecutor,
+	}
+}
+
+// NewRunnerWithSources は、フィードとサイトマップの URLSource を注入して Runner を初期化します。
+func NewRunnerWithSources(feedSource, sitemapSource URLSource, scraperExecutor ScraperExecutor) *Runner {
+	return &Runner{
+		FeedSource:      feedSource,
+		SitemapSource:   sitemapSource,
 		ScraperExecutor: scraperExecutor,
 	}
 }
 
 // RunnerConfig は実行に必要な設定を保持します。
 type RunnerConfig struct {
-	FeedURL                  string
-	ClientTimeout            time.Duration
+	FeedURLs                 []string      // 解析対象のフィードURL (複数指定可)
+	SitemapURLs              []string      // 解析対象のサイトマップ・サイトマップインデックスのURL (複数指定可)
+	Since                    time.Time     // ゼロ値でない場合、公開・更新日時がこれより前の記事を除外する
+	SinceLastRun             bool          // 状態ストアの最終実行日時より前の記事を除外する
+	Force                    bool          // 状態ストアに抽出済みとして記録されている記事も再抽出する
+	FeedConcurrency          int           // フィードを同時に取得する最大数 (0 の場合は DefaultFeedConcurrency)
+	ClientTimeout            time.Duration // 取得1回ごとのタイムアウト (全体のタイムアウトはその OverallTimeoutMultiplier 倍)
 	OverallTimeoutMultiplier int
 }
 
 // RunnerResult は ScrapeAndRun の実行結果とメタデータを保持します。
 type RunnerResult struct {
-	FeedTitle string
-	Results   []types.URLResult
-	TitlesMap map[string]string // URLをキー、記事タイトルを値とするマップ
+	FeedTitle   string
+	Feeds       []FeedSummary           // フィード・サイトマップごとの取得結果 (入力順)
+	Results     []ScrapeResult          // 入力URLの順序の最終結果 (ScrapeAndStream の場合は本文 (Content) を含まない)
+	TitlesMap   map[string]string       // URLをキー、記事タイトルを値とするマップ
+	SourcesMap  map[string][]string     // URLをキー、その記事を含んでいたフィードURLのリストを値とするマップ
+	ItemsMap    map[string]article.Item // URLをキー、フィードのアイテムのメタデータを値とするマップ (サイトマップの記事は含まない)
+	Skipped     []string                // 状態ストアに抽出済みとして記録されていたためスキップしたURL
+	NotModified bool                    // すべてのフィードが前回の取得から更新されていなかった (スクレイピングは未実行)
+
+	FeedFetchDuration time.Duration // フィード・サイトマップの取得と解析にかかった時間
+	ScrapeDuration    time.Duration // 記事のスクレイピング (リトライを含む) にかかった時間
+}
+
+// FeedItem は、記事のフィードのアイテムのメタデータを ItemsMap から返します。フィードに含まれていない記事の場合は nil を返します。
+func (r *RunnerResult) FeedItem(url string) *article.Item {
+	item, ok := r.ItemsMap[url]
+	if !ok {
+		return nil
+	}
+	return &item
 }
 
 // ScrapeAndRun は、フィードの解析から並列スクレイピングまでの一連の処理を実行し、
 // 結果データとメタデータを RunnerResult として返します。
+// 複数のフィード・サイトマップが指定された場合は並行して取得し、記事URLを重複排除してからスクレイピングします。
+// すべての記事の本文を RunnerResult.Results に保持するため、記事数が多い場合は ScrapeAndStream を使用してください。
 func (r *Runner) ScrapeAndRun(ctx context.Context, config RunnerConfig) (*RunnerResult, error) {
+	fullResults := make(map[string]*ScrapeResult)
+	runnerResult, err := r.run(ctx, "Runner.ScrapeAndRun", config, func(_ *RunnerResult, res ScrapeResult) {
+		fullResults[res.URL] = &res
+	})
+	if runnerResult != nil {
+		// ストリーミングで受け取った本文を含む結果で、本文を除いた結果を置き換える
+		for i, res := range runnerResult.Results {
+			runnerResult.Results[i] = *fullResults[res.URL]
+		}
+	}
+	return runnerResult, err
+}
+
+// ScrapeAndStream は、ScrapeAndRun と同じ処理を実行し、記事の最終結果が確定するたびに
+// (後処理を適用してから) emit に渡します。出力やメトリクスの記録をすべての記事の完了を待たずに開始でき、
+// 本文を保持しないため、記事数が多い場合もメモリの使用量を抑えられます。
+// 戻り値の RunnerResult.Results は、本文を除いた結果です (件数の集計やレポート用)。
+func (r *Runner) ScrapeAndStream(ctx context.Context, config RunnerConfig, emit ResultFunc) (*RunnerResult, error) {
+	return r.run(ctx, "Runner.ScrapeAndStream", config, emit)
+}
+
+// run は、ScrapeAndRun と ScrapeAndStream の共通の処理を、スパンを記録して実行します。
+func (r *Runner) run(ctx context.Context, spanName string, config RunnerConfig, emit ResultFunc) (*RunnerResult, error) {
+	ctx, span := tracer.Start(ctx, spanName, trace.WithAttributes(
+		attribute.Int("runner.feed_count", len(config.FeedURLs)),
+		attribute.Int("runner.sitemap_count", len(config.SitemapURLs)),
+	))
+	runnerResult, err := r.scrapeAndRun(ctx, config, emit)
+	if runnerResult != nil {
+		span.SetAttributes(
+			attribute.Int("runner.result_count", len(runnerResult.Results)),
+			attribute.Int("runner.skipped_count", len(runnerResult.Skipped)),
+			attribute.Bool("runner.not_modified", runnerResult.NotModified),
+		)
+	}
+	endSpan(span, err)
+	return runnerResult, err
+}
 
+// scrapeAndRun は ScrapeAndRun と ScrapeAndStream の処理本体です。
+func (r *Runner) scrapeAndRun(ctx context.Context, config RunnerConfig, emit ResultFunc) (*RunnerResult, error) {
+	feedURLs := uniqueStrings(config.FeedURLs)
+	sitemapURLs := uniqueStrings(config.SitemapURLs)
+	if len(feedURLs) == 0 && len(sitemapURLs) == 0 {
+		return nil, fmt.Errorf("解析対象のフィードURLまたはサイトマップURLが指定されていません")
+	}
+
+	jobs, err := r.buildSourceJobs(feedURLs, sitemapURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	startedAt := time.Now()
+	since := r.resolveSince(config)
 	overallTimeout := config.ClientTimeout * time.Duration(config.OverallTimeoutMultiplier)
 
-	runCtx, cancel := context.WithTimeout(ctx, overallTimeout)
+	// HTTPクライアントのタイムアウトより短い ClientTimeout も、取得1回ごとに適用されるようにする
+	runCtx, cancel := context.WithTimeout(WithFetchTimeout(ctx, config.ClientTimeout), overallTimeout)
 	defer cancel()
 
 	slog.Info(
 		"フィードURLを解析中",
 		slog.Duration("overall_timeout", overallTimeout),
-		slog.String("feed_url", config.FeedURL),
+		slog.Int("feed_count", len(feedURLs)),
+		slog.Int("sitemap_count", len(sitemapURLs)),
+		slog.Time("since", since),
 	)
 
-	rssFeed, err := r.FeedParser.FetchAndParse(runCtx, config.FeedURL)
-	if err != nil {
-		slog.Error(
-			"フィードの処理エラーが発生しました",
-			slog.Any("error", err),
-			slog.String("feed_url", config.FeedURL),
-		)
-		return nil, fmt.Errorf("フィードの処理エラー: %w", err)
+	// 抽出済みの記事も再抽出する場合は、更新のないフィードが 304 Not Modified とならないよう条件付きGETを行わない
+	feedCtx := runCtx
+	if config.Force {
+		feedCtx = feedcache.WithoutValidators(runCtx)
+	}
+	merged := mergeSourceItems(fetchSources(feedCtx, jobs, since, config.FeedConcurrency, metricsOrNop(r.Metrics)))
+	feedFetchDuration := time.Since(startedAt)
+
+	var feedTitles []string
+	var feedErrs []error
+	notModifiedCount := 0
+	for _, summary := range merged.feeds {
+		switch {
+		case summary.Error != nil:
+			feedErrs = append(feedErrs, fmt.Errorf("%s: %w", summary.URL, summary.Error))
+		case summary.NotModified:
+			notModifiedCount++
+		default:
+			feedTitles = append(feedTitles, summary.Title)
+		}
 	}
 
-	adapter := feed.NewFeedAdapter(rssFeed)
-	urls := adapter.GetLinks()
-	titlesMap := adapter.GetTitlesMap()
+	// すべてのフィードが失敗した場合のみエラーとし、一部の失敗は FeedSummary に記録して続行する
+	if len(feedErrs) == len(merged.feeds) {
+		return nil, fmt.Errorf("フィードの処理エラー: %w", errors.Join(feedErrs...))
+	}
+
+	// すべてのフィードが更新されていない (HTTP 304) 場合は、スクレイピングを行わずに「変更なし」の結果を返す
+	if notModifiedCount == len(merged.feeds) {
+		slog.Info("すべてのフィードが前回の取得から更新されていません", slog.Int("feed_count", notModifiedCount))
+		return &RunnerResult{Feeds: merged.feeds, NotModified: true, FeedFetchDuration: feedFetchDuration}, nil
+	}
 
 	slog.Info(
 		"フィードからURLを抽出",
-		slog.Int("extracted_count", len(urls)),
+		slog.Int("extracted_count", len(merged.urls)),
+		slog.Int("failed_feeds", len(feedErrs)),
 	)
 
+	// 日時で絞り込んだ結果や、更新のないフィードを除いた結果が0件の場合は、新しい記事がないだけなのでエラーとしない
+	if len(merged.urls) == 0 && since.IsZero() && notModifiedCount == 0 {
+		return nil, fmt.Errorf("フィード (%s) から処理対象のURLが一つも抽出されませんでした", strings.Join(append(feedURLs, sitemapURLs...), ", "))
+	}
+
+	urls, skipped := r.filterExtracted(merged.urls, config)
+
+	runnerResult := &RunnerResult{
+		FeedTitle:  strings.Join(feedTitles, " / "),
+		Feeds:      merged.feeds,
+		TitlesMap:  merged.titlesMap,
+		SourcesMap: merged.sourcesMap,
+		ItemsMap:   merged.itemsMap,
+		Skipped:    skipped,
+
+		FeedFetchDuration: feedFetchDuration,
+	}
+
 	if len(urls) == 0 {
-		return nil, fmt.Errorf("フィード (%s) から処理対象のURLが一つも抽出されませんでした", config.FeedURL)
+		// すべて抽出済みの場合はエラーではなく、結果が空の RunnerResult を返す
+		slog.Info("新しい記事はありませんでした", slog.Int("skipped", len(skipped)))
+		if r.recordState(nil, merged.titlesMap, startedAt, false) {
+			r.commitValidators(merged.feeds, nil, merged.sourcesMap)
+		}
+		return runnerResult, nil
 	}
 
 	slog.Info(
@@ -101,14 +243,32 @@ func (r *Runner) ScrapeAndRun(ctx context.Context, config RunnerConfig) (*Runner
 		slog.Int("total_urls", len(urls)),
 	)
 
-	// ScraperExecutor (ReliableScraper) を呼び出し
-	results := r.ScraperExecutor.ScrapeInParallel(runCtx, urls)
+	// ScraperExecutor (ReliableScraper) を呼び出し、確定した結果から順に後処理を適用して emit に渡す
+	// 後処理は、全体タイムアウトで打ち切られた場合も取得済みの記事に適用するため、runCtx ではなく ctx で実行する
+	scrapeStartedAt := time.Now()
+	consumeStream(ctx, scrapeStream(runCtx, r.ScraperExecutor, urls), r.Pipeline, runnerResult, urls, emit)
+	runnerResult.ScrapeDuration = time.Since(scrapeStartedAt)
 
-	runnerResult := &RunnerResult{
-		FeedTitle: rssFeed.Title,
-		Results:   results,
-		TitlesMap: titlesMap,
+	// フィードの検証子は、記事の記録に成功し、処理が中断されていない場合にのみ保存する
+	interrupted := runCtx.Err() != nil
+	if r.recordState(runnerResult.Results, merged.titlesMap, startedAt, interrupted) && !interrupted {
+		r.commitValidators(merged.feeds, runnerResult.Results, merged.sourcesMap)
 	}
 
 	return runnerResult, nil
 }
+
+// uniqueStrings は、順序を保ったまま空文字列と重複を取り除きます。
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		unique = append(unique, v)
+	}
+	return unique
+}
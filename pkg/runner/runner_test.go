@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// fakeFeedParser は、設定したフィードを返す FeedParser です。
+type fakeFeedParser struct {
+	feed *gofeed.Feed
+}
+
+func (p *fakeFeedParser) FetchAndParse(ctx context.Context, feedURL string) (*gofeed.Feed, error) {
+	return p.feed, nil
+}
+
+// fakeExecutor は、URLごとに設定した最終結果を返す ScraperExecutor です。
+// ReliableScraper と同様に、結果は入力URLの順序とは限りません。
+type fakeExecutor struct {
+	results map[string]ScrapeResult
+}
+
+func (e *fakeExecutor) ScrapeInParallel(ctx context.Context, urls []string) []ScrapeResult {
+	results := make([]ScrapeResult, 0, len(urls))
+	for i := len(urls) - 1; i >= 0; i-- {
+		results = append(results, e.results[urls[i]])
+	}
+	return results
+}
+
+func TestRunnerKeepsFailedResults(t *testing.T) {
+	parser := &fakeFeedParser{feed: &gofeed.Feed{
+		Title: "テストフィード",
+		Items: []*gofeed.Item{
+			{Title: "記事1", Link: "https://example.com/1"},
+			{Title: "記事2", Link: "https://example.com/2"},
+			{Title: "記事3", Link: "https://example.com/3"},
+		},
+	}}
+	executor := &fakeExecutor{results: map[string]ScrapeResult{
+		"https://example.com/1": {URLResult: types.URLResult{URL: "https://example.com/1", Content: "本文1"}, Attempts: 1, Phase: ResultPhaseParallel},
+		"https://example.com/2": {URLResult: types.URLResult{URL: "https://example.com/2", Error: errors.New("抽出エラー")}, Attempts: 3, Phase: ResultPhaseRetry},
+		"https://example.com/3": {URLResult: types.URLResult{URL: "https://example.com/3", Content: "本文3"}, Attempts: 2, Phase: ResultPhaseRetry},
+	}}
+
+	result, err := NewRunner(parser, executor).ScrapeAndRun(context.Background(), RunnerConfig{
+		FeedURLs:                 []string{"https://example.com/feed.xml"},
+		ClientTimeout:            time.Minute,
+		OverallTimeoutMultiplier: 1,
+	})
+	if err != nil {
+		t.Fatalf("ScrapeAndRun() error = %v", err)
+	}
+
+	want := []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"}
+	if len(result.Results) != len(want) {
+		t.Fatalf("len(Results) = %d, want %d", len(result.Results), len(want))
+	}
+	for i, url := range want {
+		got := result.Results[i]
+		if got.URL != url {
+			t.Errorf("Results[%d].URL = %q, want %q", i, got.URL, url)
+			continue
+		}
+		if w := executor.results[url]; got.Content != w.Content || (got.Error != nil) != (w.Error != nil) || got.Attempts != w.Attempts || got.Phase != w.Phase {
+			t.Errorf("Results[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+	if result.TitlesMap["https://example.com/2"] != "記事2" {
+		t.Errorf("TitlesMap[2] = %q, want %q", result.TitlesMap["https://example.com/2"], "記事2")
+	}
+}
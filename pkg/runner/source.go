@@ -0,0 +1,167 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/article"
+	"github.com/shouni/web-text-pipe-go/pkg/feedcache"
+	"github.com/shouni/web-text-pipe-go/pkg/sitemap"
+
+	"github.com/shouni/go-web-exact/v2/pkg/feed"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ----------------------------------------------------------------
+// URLソース (URLSource) - スクレイピング対象URLの列挙
+// ----------------------------------------------------------------
+
+const (
+	SourceKindFeed    = "feed"
+	SourceKindSitemap = "sitemap"
+)
+
+// URLSource は、フィードやサイトマップなどからスクレイピング対象の記事URLを列挙する機能を提供します。
+type URLSource interface {
+	// FetchItems は sourceURL を取得・解析し、含まれる記事を返します。
+	// since がゼロ値でない場合、実装は日時が since より前と判明している記事を除外してもかまいません。
+	FetchItems(ctx context.Context, sourceURL string, since time.Time) (*SourceItems, error)
+}
+
+// SourceItems は、1つのURLソースから列挙された記事の一覧です。
+type SourceItems struct {
+	Title          string
+	Items          []SourceItem
+	UpdateInterval time.Duration // ソースが宣言する更新間隔 (RSS の <ttl> など)。不明な場合はゼロ値
+}
+
+// SourceItem は、URLソースに含まれる1記事分の情報です。
+type SourceItem struct {
+	URL       string
+	Title     string
+	UpdatedAt time.Time // 記事の公開・更新日時。不明な場合はゼロ値
+
+	FeedItem *article.Item // フィードのアイテムのメタデータ (フィード以外のURLソースの場合は nil)
+}
+
+// ValidatorCommitter は、URLソースの取得時に受け取った検証子 (ETag / Last-Modified) を保存する機能を提供します。
+// Runner は、ソースの記事をすべて処理し終えた場合にのみ CommitValidators を呼び出します。
+// 検証子を先に保存すると、記事の抽出に失敗した場合や処理が中断された場合に、次回の取得が
+// 304 Not Modified となり、未処理の記事が再取得されなくなるためです。
+// feedcache.ConditionalParser がこのインターフェースを実装します。
+type ValidatorCommitter interface {
+	CommitValidators(sourceURL string) error
+}
+
+// SitemapParser はサイトマップの取得と解析機能を提供します。
+type SitemapParser interface {
+	FetchAndParse(ctx context.Context, sitemapURL string, since time.Time) ([]sitemap.URL, error)
+}
+
+// FeedSource は FeedParser (gofeed) を URLSource として扱うためのアダプターです。
+type FeedSource struct {
+	parser FeedParser
+}
+
+// NewFeedSource は FeedParser を注入して FeedSource を初期化します。
+func NewFeedSource(parser FeedParser) *FeedSource {
+	return &FeedSource{parser: parser}
+}
+
+// FetchItems はフィードを取得・解析し、記事のURL・タイトル・公開日時と、アイテムのメタデータを返します。
+func (s *FeedSource) FetchItems(ctx context.Context, feedURL string, since time.Time) (*SourceItems, error) {
+	ctx, span := tracer.Start(ctx, "FeedParser.FetchAndParse", trace.WithAttributes(attribute.String("feed.url", feedURL)))
+	rssFeed, err := s.parser.FetchAndParse(ctx, feedURL)
+	if errors.Is(err, feedcache.ErrNotModified) {
+		// 更新がないことは失敗ではないため、エラーとして記録しない
+		span.SetAttributes(attribute.Bool("feed.not_modified", true))
+		span.End()
+		return nil, err
+	}
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("feed.item_count", len(rssFeed.Items)))
+	span.End()
+
+	adapter := feed.NewFeedAdapter(rssFeed)
+	titlesMap := adapter.GetTitlesMap()
+
+	// 公開日時や著者などはアダプターから取得できないため、フィードのアイテムから直接参照する
+	datesMap := make(map[string]time.Time, len(rssFeed.Items))
+	itemsMap := make(map[string]*article.Item, len(rssFeed.Items))
+	for _, item := range rssFeed.Items {
+		switch {
+		case item.UpdatedParsed != nil:
+			datesMap[item.Link] = *item.UpdatedParsed
+		case item.PublishedParsed != nil:
+			datesMap[item.Link] = *item.PublishedParsed
+		}
+		if _, ok := itemsMap[item.Link]; !ok {
+			meta := article.FromFeedItem(item)
+			itemsMap[item.Link] = &meta
+		}
+	}
+
+	links := adapter.GetLinks()
+	items := make([]SourceItem, 0, len(links))
+	for _, link := range links {
+		items = append(items, SourceItem{
+			URL:       link,
+			Title:     titlesMap[link],
+			UpdatedAt: datesMap[link],
+			FeedItem:  itemsMap[link],
+		})
+	}
+
+	return &SourceItems{
+		Title:          rssFeed.Title,
+		Items:          items,
+		UpdateInterval: feedUpdateInterval(rssFeed),
+	}, nil
+}
+
+// CommitValidators は、FeedParser が ValidatorCommitter を実装している場合に、フィードの検証子を保存します。
+func (s *FeedSource) CommitValidators(feedURL string) error {
+	if committer, ok := s.parser.(ValidatorCommitter); ok {
+		return committer.CommitValidators(feedURL)
+	}
+	return nil
+}
+
+// SitemapSource は SitemapParser を URLSource として扱うためのアダプターです。
+type SitemapSource struct {
+	parser SitemapParser
+}
+
+// NewSitemapSource は SitemapParser を注入して SitemapSource を初期化します。
+func NewSitemapSource(parser SitemapParser) *SitemapSource {
+	return &SitemapSource{parser: parser}
+}
+
+// FetchItems はサイトマップ (インデックスを含む) を取得・解析し、記事のURLと最終更新日時を返します。
+func (s *SitemapSource) FetchItems(ctx context.Context, sitemapURL string, since time.Time) (*SourceItems, error) {
+	ctx, span := tracer.Start(ctx, "SitemapParser.FetchAndParse", trace.WithAttributes(attribute.String("sitemap.url", sitemapURL)))
+	urls, err := s.parser.FetchAndParse(ctx, sitemapURL, since)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("sitemap.url_count", len(urls)))
+	span.End()
+
+	items := make([]SourceItem, 0, len(urls))
+	for _, u := range urls {
+		items = append(items, SourceItem{
+			URL:       u.Loc,
+			Title:     u.Title,
+			UpdatedAt: u.LastMod,
+		})
+	}
+
+	// サイトマップにはタイトルがないため、サイトマップのURLをタイトルとして扱う
+	return &SourceItems{Title: sitemapURL, Items: items}, nil
+}
@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/article"
+	"github.com/shouni/web-text-pipe-go/pkg/feedcache"
+)
+
+// ----------------------------------------------------------------
+// 複数URLソース (フィード・サイトマップ) の並行取得とマージ
+// ----------------------------------------------------------------
+
+// DefaultFeedConcurrency は、フィードやサイトマップを同時に取得する最大数のデフォルト値です。
+const DefaultFeedConcurrency = 8
+
+// FeedSummary は、1つのURLソース (フィードまたはサイトマップ) の取得結果の概要です。
+type FeedSummary struct {
+	URL            string        // フィード・サイトマップのURL
+	Kind           string        // ソースの種類 (SourceKindFeed / SourceKindSitemap)
+	Title          string        // フィードのタイトル (サイトマップの場合はURL)
+	ItemCount      int           // 抽出された記事URLの数
+	UpdateInterval time.Duration // フィードが宣言する更新間隔 (<ttl> / sy:updatePeriod)。不明な場合はゼロ値
+	NotModified    bool          // 条件付きGETにより、前回の取得から更新されていないと判定された
+	Error          error         // 取得・解析に失敗した場合のエラー
+}
+
+// sourceJob は、取得対象のURLソースとそのURLの組です。
+type sourceJob struct {
+	kind   string
+	url    string
+	source URLSource
+}
+
+// sourceItems は、1つのURLソースから抽出された記事を保持します。
+type sourceItems struct {
+	summary FeedSummary
+	items   []SourceItem
+}
+
+// mergedFeeds は、複数URLソースの記事URLを重複排除してマージした結果です。
+type mergedFeeds struct {
+	feeds      []FeedSummary
+	urls       []string
+	titlesMap  map[string]string
+	sourcesMap map[string][]string
+	itemsMap   map[string]article.Item
+}
+
+// buildSourceJobs は、設定されたフィードURLとサイトマップURLから取得ジョブを構築します。
+func (r *Runner) buildSourceJobs(feedURLs, sitemapURLs []string) ([]sourceJob, error) {
+	jobs := make([]sourceJob, 0, len(feedURLs)+len(sitemapURLs))
+	if len(feedURLs) > 0 && r.FeedSource == nil {
+		return nil, fmt.Errorf("フィードURLが指定されましたが、FeedSource が設定されていません")
+	}
+	for _, u := range feedURLs {
+		jobs = append(jobs, sourceJob{kind: SourceKindFeed, url: u, source: r.FeedSource})
+	}
+	if len(sitemapURLs) > 0 && r.SitemapSource == nil {
+		return nil, fmt.Errorf("サイトマップURLが指定されましたが、SitemapSource が設定されていません")
+	}
+	for _, u := range sitemapURLs {
+		jobs = append(jobs, sourceJob{kind: SourceKindSitemap, url: u, source: r.SitemapSource})
+	}
+	return jobs, nil
+}
+
+// fetchSources は、複数のURLソースを最大 concurrency 件ずつ並行して取得・解析します。
+// 戻り値はジョブの入力順に並びます。個々のソースのエラーは FeedSummary.Error に記録されます。
+func fetchSources(ctx context.Context, jobs []sourceJob, since time.Time, concurrency int, metrics MetricsRecorder) []sourceItems {
+	if concurrency <= 0 {
+		concurrency = DefaultFeedConcurrency
+	}
+
+	results := make([]sourceItems, len(jobs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job sourceJob) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = fetchSource(ctx, job, since)
+			if results[i].summary.Error != nil {
+				metrics.FeedFetchFailed(job.kind, job.url)
+			}
+		}(i, job)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// fetchSource は、単一のURLソースを取得・解析し、since 以降の記事を抽出します。
+func fetchSource(ctx context.Context, job sourceJob, since time.Time) sourceItems {
+	summary := FeedSummary{URL: job.url, Kind: job.kind}
+
+	fetched, err := job.source.FetchItems(ctx, job.url, since)
+	if errors.Is(err, feedcache.ErrNotModified) {
+		slog.Info("フィードは前回の取得から更新されていません", slog.String("kind", job.kind), slog.String("feed_url", job.url))
+		summary.NotModified = true
+		return sourceItems{summary: summary}
+	}
+	if err != nil {
+		slog.Error(
+			"フィードの処理エラーが発生しました",
+			slog.Any("error", err),
+			slog.String("kind", job.kind),
+			slog.String("feed_url", job.url),
+		)
+		summary.Error = err
+		return sourceItems{summary: summary}
+	}
+
+	// 日時が判明しており since より前の記事を除外する (URLSource 側で除外されていない場合に備える)
+	items := make([]SourceItem, 0, len(fetched.Items))
+	for _, item := range fetched.Items {
+		if !since.IsZero() && !item.UpdatedAt.IsZero() && item.UpdatedAt.Before(since) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	slog.Info(
+		"フィードからURLを抽出",
+		slog.String("kind", job.kind),
+		slog.String("feed_url", job.url),
+		slog.Int("extracted_count", len(items)),
+		slog.Int("skipped_by_date", len(fetched.Items)-len(items)),
+	)
+
+	summary.Title = fetched.Title
+	summary.ItemCount = len(items)
+	summary.UpdateInterval = fetched.UpdateInterval
+	return sourceItems{summary: summary, items: items}
+}
+
+// mergeSourceItems は、複数URLソースの記事URLをソース順・記事順を保ったまま重複排除してマージし、
+// 各記事がどのソースに含まれていたかを記録します。
+// 複数のフィードに含まれる記事のタイトルとアイテムのメタデータは、最初のフィードの値を使用します。
+func mergeSourceItems(results []sourceItems) mergedFeeds {
+	merged := mergedFeeds{
+		feeds:      make([]FeedSummary, 0, len(results)),
+		titlesMap:  make(map[string]string),
+		sourcesMap: make(map[string][]string),
+		itemsMap:   make(map[string]article.Item),
+	}
+
+	for _, result := range results {
+		merged.feeds = append(merged.feeds, result.summary)
+		for _, item := range result.items {
+			sources, seen := merged.sourcesMap[item.URL]
+			if !seen {
+				merged.urls = append(merged.urls, item.URL)
+			}
+			// 同じフィードに複数回含まれる記事も、ソースは1回だけ記録する
+			if !slices.Contains(sources, result.summary.URL) {
+				merged.sourcesMap[item.URL] = append(sources, result.summary.URL)
+			}
+			if item.Title != "" && merged.titlesMap[item.URL] == "" {
+				merged.titlesMap[item.URL] = item.Title
+			}
+			if _, ok := merged.itemsMap[item.URL]; !ok && item.FeedItem != nil {
+				merged.itemsMap[item.URL] = *item.FeedItem
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergeSourceItems(t *testing.T) {
+	feedA := "https://example.com/a.xml"
+	feedB := "https://example.com/b.xml"
+	merged := mergeSourceItems([]sourceItems{
+		{
+			summary: FeedSummary{URL: feedA},
+			items: []SourceItem{
+				{URL: "https://example.com/1", Title: "記事1"},
+				{URL: "https://example.com/2"},
+				{URL: "https://example.com/1", Title: "記事1 (重複)"},
+			},
+		},
+		{
+			summary: FeedSummary{URL: feedB},
+			items: []SourceItem{
+				{URL: "https://example.com/2", Title: "記事2"},
+				{URL: "https://example.com/3", Title: "記事3"},
+			},
+		},
+	})
+
+	if want := []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"}; !slices.Equal(merged.urls, want) {
+		t.Errorf("urls = %v, want %v", merged.urls, want)
+	}
+	wantSources := map[string][]string{
+		"https://example.com/1": {feedA},
+		"https://example.com/2": {feedA, feedB},
+		"https://example.com/3": {feedB},
+	}
+	for url, want := range wantSources {
+		if got := merged.sourcesMap[url]; !slices.Equal(got, want) {
+			t.Errorf("sourcesMap[%s] = %v, want %v", url, got, want)
+		}
+	}
+	wantTitles := map[string]string{
+		"https://example.com/1": "記事1",
+		"https://example.com/2": "記事2",
+		"https://example.com/3": "記事3",
+	}
+	for url, want := range wantTitles {
+		if got := merged.titlesMap[url]; got != want {
+			t.Errorf("titlesMap[%s] = %q, want %q", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/pipeline"
+	"github.com/shouni/web-text-pipe-go/pkg/state"
+)
+
+// ----------------------------------------------------------------
+// 状態ストア (StateStore) - インクリメンタルスクレイピング
+// ----------------------------------------------------------------
+
+// StateStore は、抽出済みURLと最終実行日時の記録・参照機能を提供します。
+// state.Store がこのインターフェースを実装します。
+type StateStore interface {
+	IsExtracted(url string) (bool, error)
+	MarkExtracted(entries []state.Entry) error
+	LastRun() (time.Time, error)
+	SetLastRun(t time.Time) error
+}
+
+// resolveSince は、--since-last-run が指定された場合に、最終実行日時と config.Since の新しい方を返します。
+func (r *Runner) resolveSince(config RunnerConfig) time.Time {
+	since := config.Since
+	if r.StateStore == nil || !config.SinceLastRun {
+		return since
+	}
+
+	lastRun, err := r.StateStore.LastRun()
+	if err != nil {
+		slog.Warn("最終実行日時を取得できませんでした。日時による絞り込みは --since のみで行います", slog.Any("error", err))
+		return since
+	}
+	if lastRun.After(since) {
+		since = lastRun
+	}
+	return since
+}
+
+// filterExtracted は、状態ストアに抽出済みとして記録されているURLを除外します。
+// config.Force が指定された場合や状態ストアが設定されていない場合は、何も除外しません。
+func (r *Runner) filterExtracted(urls []string, config RunnerConfig) (pending []string, skipped []string) {
+	if r.StateStore == nil || config.Force {
+		return urls, nil
+	}
+
+	for _, url := range urls {
+		extracted, err := r.StateStore.IsExtracted(url)
+		if err != nil {
+			// 判定できない場合は、取りこぼしを避けるため抽出対象に含める
+			slog.Warn("抽出済みかどうかを判定できませんでした", slog.String("url", url), slog.Any("error", err))
+		}
+		if extracted {
+			skipped = append(skipped, url)
+			continue
+		}
+		pending = append(pending, url)
+	}
+
+	slog.Info(
+		"抽出済みの記事を除外しました",
+		slog.Int("skipped", len(skipped)),
+		slog.Int("pending", len(pending)),
+	)
+	return pending, skipped
+}
+
+// recordState は、抽出に成功した記事と実行開始日時を状態ストアに記録します。
+// 後処理のステージで除外された記事も、抽出には成功しているため抽出済みとして記録します。
+// 処理が中断された場合は、未処理の記事を次回の --since-last-run で取りこぼさないよう実行日時を更新しません。
+// 抽出済みの記事を記録できた場合 (状態ストアが設定されていない場合を含む) に true を返します。
+func (r *Runner) recordState(results []ScrapeResult, titlesMap map[string]string, startedAt time.Time, interrupted bool) bool {
+	if r.StateStore == nil {
+		return true
+	}
+
+	now := time.Now()
+	var entries []state.Entry
+	for _, res := range results {
+		if res.Error != nil && !errors.Is(res.Error, pipeline.ErrDropped) {
+			continue
+		}
+		entries = append(entries, state.Entry{URL: res.URL, Title: titlesMap[res.URL], ExtractedAt: now})
+	}
+
+	if err := r.StateStore.MarkExtracted(entries); err != nil {
+		slog.Warn("抽出済みの記事を状態ストアに記録できませんでした", slog.Any("error", err))
+		return false
+	}
+	if interrupted {
+		return true
+	}
+	if err := r.StateStore.SetLastRun(startedAt); err != nil {
+		slog.Warn("最終実行日時を状態ストアに記録できませんでした", slog.Any("error", err))
+	}
+	return true
+}
+
+// commitValidators は、記事をすべて処理し終えたフィードの検証子を保存します (FeedSource が ValidatorCommitter を実装している場合)。
+// 抽出に失敗した記事 (後処理の失敗を含む) を含むフィードは、次回も記事を再取得できるよう検証子を保存しません。
+// robots.txt で許可されていない記事と後処理で除外された記事は、再取得しても結果が変わらないため失敗として扱いません。
+func (r *Runner) commitValidators(feeds []FeedSummary, results []ScrapeResult, sourcesMap map[string][]string) {
+	committer, ok := r.FeedSource.(ValidatorCommitter)
+	if !ok {
+		return
+	}
+
+	incomplete := make(map[string]bool)
+	for _, res := range results {
+		if res.Error == nil || errors.Is(res.Error, pipeline.ErrDropped) || errors.Is(res.Error, ErrDisallowedByRobots) {
+			continue
+		}
+		for _, source := range sourcesMap[res.URL] {
+			incomplete[source] = true
+		}
+	}
+
+	for _, summary := range feeds {
+		if summary.Kind != SourceKindFeed || summary.Error != nil || summary.NotModified {
+			continue
+		}
+		if incomplete[summary.URL] {
+			slog.Info("抽出に失敗した記事があるため、フィードの検証子を保存しません", slog.String("feed_url", summary.URL))
+			continue
+		}
+		if err := committer.CommitValidators(summary.URL); err != nil {
+			slog.Warn("フィードの検証子を保存できませんでした", slog.String("feed_url", summary.URL), slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"context"
+)
+
+// ----------------------------------------------------------------
+// 結果のストリーミング
+// ----------------------------------------------------------------
+
+// StreamingExecutor は、最終結果が確定したURLから順に結果を送信する ScraperExecutor です。
+// ReliableScraper がこのインターフェースを実装します。
+type StreamingExecutor interface {
+	ScraperExecutor
+	// ScrapeStream は、すべての入力URLの最終結果を確定した順に送信し、送信を終えるとチャネルを閉じます。
+	ScrapeStream(ctx context.Context, urls []string) <-chan ScrapeResult
+}
+
+// ResultFunc は、記事の最終結果が確定するたびに呼び出されるコールバックです。
+// runnerResult にはフィードの情報 (FeedTitle / Feeds / TitlesMap / SourcesMap) が設定済みで、
+// Results はまだ設定されていません。コールバックは1つのゴルーチンから順に呼び出されます。
+type ResultFunc func(runnerResult *RunnerResult, res ScrapeResult)
+
+// StreamResults は、executor で urls をスクレイピングし、最終結果が確定するたびに後処理を適用して emit に渡します。
+// executor が StreamingExecutor を実装していない場合は、すべての結果が確定してから順に渡します。
+// 完了後、runnerResult.Results に本文を除いた結果を入力URLの順序で設定します (件数の集計やレポート用)。
+// ReliableScraper を直接使用する場合 (batch コマンドなど) に、Runner.ScrapeAndStream と同じ方法で結果を受け取るために使用します。
+func StreamResults(ctx context.Context, executor ScraperExecutor, processor RecordProcessor, runnerResult *RunnerResult, urls []string, emit ResultFunc) {
+	consumeStream(ctx, scrapeStream(ctx, executor, urls), processor, runnerResult, urls, emit)
+}
+
+// scrapeStream は、executor による最終結果を確定した順に送信するチャネルを返します。
+func scrapeStream(ctx context.Context, executor ScraperExecutor, urls []string) <-chan ScrapeResult {
+	if streaming, ok := executor.(StreamingExecutor); ok {
+		return streaming.ScrapeStream(ctx, urls)
+	}
+	results := executor.ScrapeInParallel(ctx, urls)
+	resultsChan := make(chan ScrapeResult, len(results))
+	for _, res := range results {
+		resultsChan <- res
+	}
+	close(resultsChan)
+	return resultsChan
+}
+
+// consumeStream は、チャネルから受信した結果に後処理を適用して emit に渡し、
+// 本文を除いた結果を入力URLの順序で runnerResult.Results に設定します。
+// 本文は保持しないため、記事数が多い場合もメモリの使用量は本文の量に比例して増えません。
+func consumeStream(ctx context.Context, results <-chan ScrapeResult, processor RecordProcessor, runnerResult *RunnerResult, urls []string, emit ResultFunc) {
+	var stats pipelineStats
+	if processor != nil {
+		pipelineCtx, span := tracer.Start(ctx, "Runner.pipeline")
+		defer span.End()
+		defer stats.log(span)
+		ctx = pipelineCtx
+	}
+
+	summaries := make(map[string]*ScrapeResult, len(urls))
+	for res := range results {
+		if processor != nil {
+			res = applyPipeline(ctx, processor, runnerResult, res)
+			stats.add(res)
+		}
+		if emit != nil {
+			emit(runnerResult, res)
+		}
+		summary := res
+		summary.Content = ""
+		summaries[res.URL] = &summary
+	}
+	runnerResult.Results = orderedResults(urls, summaries)
+}
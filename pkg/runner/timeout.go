@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"context"
+	"time"
+
+	"github.com/shouni/go-web-exact/v2/pkg/extract"
+)
+
+// fetchTimeoutKey は、取得1回ごとのタイムアウトをコンテキストに格納するためのキーです。
+type fetchTimeoutKey struct{}
+
+// WithFetchTimeout は、NewTimeoutFetcher の Fetcher が取得1回ごとに適用するタイムアウトを設定したコンテキストを返します。
+// Runner は、RunnerConfig.ClientTimeout をこの方法で記事・フィード・サイトマップの取得に適用します。
+func WithFetchTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, fetchTimeoutKey{}, timeout)
+}
+
+// timeoutFetcher は、コンテキストに設定されたタイムアウトを取得1回ごとに適用する extract.Fetcher です。
+type timeoutFetcher struct {
+	fetcher extract.Fetcher
+}
+
+// NewTimeoutFetcher は、WithFetchTimeout で設定されたタイムアウトを取得1回ごと (HTTP層のリトライを含む) に適用する extract.Fetcher を返します。
+// HTTPクライアントのタイムアウトより短いタイムアウトを実行ごとに指定する場合 (serve コマンドの timeout_sec など) に使用します。
+// コンテキストにタイムアウトが設定されていない場合は、そのまま取得します。
+func NewTimeoutFetcher(fetcher extract.Fetcher) extract.Fetcher {
+	return &timeoutFetcher{fetcher: fetcher}
+}
+
+func (f *timeoutFetcher) FetchBytes(ctx context.Context, url string) ([]byte, error) {
+	timeout, _ := ctx.Value(fetchTimeoutKey{}).(time.Duration)
+	if timeout <= 0 {
+		return f.fetcher.FetchBytes(ctx, url)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return f.fetcher.FetchBytes(ctx, url)
+}
@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ----------------------------------------------------------------
+// トレーシング (OpenTelemetry)
+// ----------------------------------------------------------------
+
+// tracer は、Runner と ReliableScraper のスパンを生成します。
+// グローバルな TracerProvider が設定されていない場合、スパンは記録されません。
+var tracer = otel.Tracer("github.com/shouni/web-text-pipe-go/pkg/runner")
+
+// endSpan は、err が nil でない場合にスパンにエラーを記録してから終了します。
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,378 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/article"
+	"github.com/shouni/web-text-pipe-go/pkg/charset"
+	"github.com/shouni/web-text-pipe-go/pkg/jobs"
+	"github.com/shouni/web-text-pipe-go/pkg/output"
+	"github.com/shouni/web-text-pipe-go/pkg/pipeline"
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+	"github.com/shouni/web-text-pipe-go/pkg/urllist"
+)
+
+const (
+	DefaultAddr          = ":8080"
+	DefaultMaxConcurrent = 4
+	DefaultMaxTimeout    = 120 * time.Second
+
+	// overallTimeoutMultiplier は、scraper コマンドと同じく ClientTimeout に対する全体タイムアウトの倍率です。
+	overallTimeoutMultiplier = 3
+	maxRequestBodyBytes      = 1 * 1024 * 1024
+	shutdownTimeout          = 30 * time.Second
+)
+
+// ExtractFunc は、単一URLから本文を抽出する処理です。cmd の runExactExtraction を注入します。
+// 判定したページの文字コードとページのメタデータは、ctx に設定された charset.Recorder・article.Recorder に記録されます。
+type ExtractFunc func(ctx context.Context, url string) (text string, isBodyExtracted bool, err error)
+
+// Config は、Server の動作設定を保持します。
+type Config struct {
+	Addr           string        // 待ち受けアドレス
+	MaxConcurrent  int           // 同時に処理する /extract・/scrape リクエストの最大数
+	DefaultTimeout time.Duration // リクエストでタイムアウトが指定されない場合の ClientTimeout
+	MaxTimeout     time.Duration // リクエストで指定できる ClientTimeout の上限
+}
+
+// Server は、exact / scraper の機能を HTTP API として提供します。
+type Server struct {
+	runner  *runner.Runner
+	extract ExtractFunc
+	config  Config
+	sem     chan struct{}
+	ready   atomic.Bool
+
+	Jobs           *jobs.Queue  // 非同期ジョブキュー (nil の場合は /jobs を提供しない)
+	MetricsHandler http.Handler // /metrics のハンドラ (nil の場合は /metrics を提供しない)
+}
+
+// New は、Runner と抽出処理を注入して Server を初期化します。
+func New(r *runner.Runner, extract ExtractFunc, config Config) *Server {
+	if config.Addr == "" {
+		config.Addr = DefaultAddr
+	}
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = DefaultMaxConcurrent
+	}
+	if config.MaxTimeout <= 0 {
+		config.MaxTimeout = DefaultMaxTimeout
+	}
+	if config.DefaultTimeout <= 0 || config.DefaultTimeout > config.MaxTimeout {
+		config.DefaultTimeout = min(15*time.Second, config.MaxTimeout)
+	}
+	return &Server{
+		runner:  r,
+		extract: extract,
+		config:  config,
+		sem:     make(chan struct{}, config.MaxConcurrent),
+	}
+}
+
+// Handler は、API のルーティングを設定した http.Handler を返します。
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /extract", s.limit(s.handleExtract))
+	mux.HandleFunc("POST /scrape", s.limit(s.handleScrape))
+	if s.Jobs != nil {
+		mux.HandleFunc("POST /jobs", s.handleCreateJob)
+		mux.HandleFunc("GET /jobs/{id}", s.handleGetJob)
+	}
+	if s.MetricsHandler != nil {
+		mux.Handle("GET /metrics", s.MetricsHandler)
+	}
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	return mux
+}
+
+// ListenAndServe は、ctx がキャンセルされるまで HTTP サーバーを実行します。
+// キャンセル後は readyz を失敗させて新規リクエストの受け付けを止め、処理中のリクエストの完了を待ってから終了します。
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.config.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		// 最長のスクレイピング (ClientTimeout の上限 × 全体タイムアウトの倍率) に余裕を持たせる
+		WriteTimeout: s.config.MaxTimeout*overallTimeoutMultiplier + 10*time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("HTTPサーバーを起動しました", slog.String("addr", s.config.Addr), slog.Int("max_concurrent", s.config.MaxConcurrent))
+		s.ready.Store(true)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		s.ready.Store(false)
+		return fmt.Errorf("HTTPサーバーの実行エラー: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("HTTPサーバーを停止します。処理中のリクエストの完了を待機します。")
+	s.ready.Store(false)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("HTTPサーバーの停止エラー: %w", err)
+	}
+	return nil
+}
+
+// --- リクエスト / レスポンス ---
+
+// ExtractRequest は POST /extract のリクエストボディです。
+type ExtractRequest struct {
+	URL        string `json:"url"`
+	TimeoutSec int    `json:"timeout_sec,omitempty"`
+}
+
+// ExtractResponse は POST /extract のレスポンスボディです。
+type ExtractResponse struct {
+	URL             string        `json:"url"`
+	Content         string        `json:"content"`
+	IsBodyExtracted bool          `json:"is_body_extracted"`
+	Charset         string        `json:"charset,omitempty"` // 取得したページについて判定した文字コード
+	Page            *article.Page `json:"page,omitempty"`    // ページのメタデータ (canonical・lang・OpenGraph・JSON-LD)
+}
+
+// ScrapeRequest は POST /scrape のリクエストボディです。
+type ScrapeRequest struct {
+	FeedURLs    []string  `json:"feed_urls"`
+	SitemapURLs []string  `json:"sitemap_urls,omitempty"`
+	Since       time.Time `json:"since,omitempty"`
+	TimeoutSec  int       `json:"timeout_sec,omitempty"`
+}
+
+// JobResponse は POST /jobs・GET /jobs/{id} のレスポンスボディです。
+type JobResponse struct {
+	ID         string          `json:"id"`
+	Status     jobs.Status     `json:"status"`
+	Progress   runner.Progress `json:"progress"`
+	Result     *output.Result  `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  time.Time       `json:"started_at,omitzero"`
+	FinishedAt time.Time       `json:"finished_at,omitzero"`
+}
+
+// errorResponse は、エラー時のレスポンスボディです。
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// --- ハンドラ ---
+
+func (s *Server) handleExtract(w http.ResponseWriter, r *http.Request) {
+	var req ExtractRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := urllist.Validate(req.URL); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// 単一抽出のため、exact コマンドと同じくHTTPクライアントのタイムアウトをリクエスト全体のタイムアウトとする
+	ctx, cancel := context.WithTimeout(r.Context(), s.clientTimeout(req.TimeoutSec))
+	defer cancel()
+	charsets := charset.NewRecorder()
+	ctx = charset.WithRecorder(ctx, charsets)
+	pages := article.NewRecorder()
+	ctx = article.WithRecorder(ctx, pages)
+
+	text, isBodyExtracted, err := s.extract(ctx, req.URL)
+	if err != nil {
+		writeError(w, statusForError(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ExtractResponse{
+		URL:             req.URL,
+		Content:         text,
+		IsBodyExtracted: isBodyExtracted,
+		Charset:         charsets.Charset(req.URL),
+		Page:            pages.Page(req.URL),
+	})
+}
+
+func (s *Server) handleScrape(w http.ResponseWriter, r *http.Request) {
+	var req ScrapeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validateScrapeRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	runnerResult, err := s.runner.ScrapeAndRun(r.Context(), s.runnerConfig(req))
+	if err != nil {
+		writeError(w, statusForError(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, output.NewResult(runnerResult))
+}
+
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	var req ScrapeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validateScrapeRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	job, err := s.Jobs.Enqueue(s.runnerConfig(req))
+	if errors.Is(err, jobs.ErrQueueFull) {
+		w.Header().Set("Retry-After", "60")
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, NewJobResponse(job))
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := s.Jobs.Get(r.PathValue("id"))
+	if errors.Is(err, jobs.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewJobResponse(job))
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if !s.ready.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// NewJobResponse は、ジョブを POST /jobs・GET /jobs/{id} のレスポンスに変換します。
+func NewJobResponse(job *jobs.Job) JobResponse {
+	return JobResponse{
+		ID:         job.ID,
+		Status:     job.Status,
+		Progress:   job.Progress,
+		Result:     job.Result,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
+
+// --- ヘルパー ---
+
+// limit は、同時処理数の上限を超えるリクエストを 503 で拒否するミドルウェアです。
+func (s *Server) limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case s.sem <- struct{}{}:
+			defer func() { <-s.sem }()
+			next(w, r)
+		default:
+			w.Header().Set("Retry-After", "1")
+			writeError(w, http.StatusServiceUnavailable, errors.New("同時処理数の上限に達しています。時間をおいて再試行してください"))
+		}
+	}
+}
+
+// clientTimeout は、リクエストで指定されたタイムアウト (秒) を上限の範囲に収めて返します。
+func (s *Server) clientTimeout(timeoutSec int) time.Duration {
+	if timeoutSec <= 0 {
+		return s.config.DefaultTimeout
+	}
+	return min(time.Duration(timeoutSec)*time.Second, s.config.MaxTimeout)
+}
+
+// runnerConfig は、ScrapeRequest から Runner の実行設定を構築します。
+func (s *Server) runnerConfig(req ScrapeRequest) runner.RunnerConfig {
+	return runner.RunnerConfig{
+		FeedURLs:                 req.FeedURLs,
+		SitemapURLs:              req.SitemapURLs,
+		Since:                    req.Since,
+		ClientTimeout:            s.clientTimeout(req.TimeoutSec),
+		OverallTimeoutMultiplier: overallTimeoutMultiplier,
+	}
+}
+
+// validateScrapeRequest は、ScrapeRequest のフィードURLとサイトマップURLを検証します。
+func validateScrapeRequest(req ScrapeRequest) error {
+	if len(req.FeedURLs) == 0 && len(req.SitemapURLs) == 0 {
+		return errors.New("feed_urls または sitemap_urls を指定してください")
+	}
+	// req.FeedURLs の基底配列を書き換えないよう、新しいスライスに連結する
+	for _, u := range slices.Concat(req.FeedURLs, req.SitemapURLs) {
+		if err := urllist.Validate(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statusForError は、エラーの種類に応じたHTTPステータスコードを返します。
+func statusForError(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	if errors.Is(err, pipeline.ErrDropped) {
+		// 取得と抽出には成功しているが、後処理のステージで除外された
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadGateway
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("リクエストボディのJSONデコードエラー: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		slog.Error("レスポンスのJSONエンコードエラー", slog.Any("error", err))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shouni/web-text-pipe-go/pkg/jobs"
+)
+
+// doRequest は、Server のハンドラにリクエストを送信し、レスポンスを返します。
+func doRequest(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec
+}
+
+func TestBadRequest(t *testing.T) {
+	extract := func(ctx context.Context, url string) (string, bool, error) {
+		t.Errorf("extract called for %s", url)
+		return "", false, nil
+	}
+	h := New(nil, extract, Config{}).Handler()
+
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{"不正なJSON", "/extract", `{"url": `},
+		{"不明なフィールド", "/extract", `{"url": "https://example.com/a", "timeout": 10}`},
+		{"無効なURL", "/extract", `{"url": "example.com/a"}`},
+		{"scrape の不明なフィールド", "/scrape", `{"feed_urls": ["https://example.com/feed.xml"], "concurrency": 2}`},
+		{"フィードURLの指定なし", "/scrape", `{"feed_urls": []}`},
+		{"無効なサイトマップURL", "/scrape", `{"sitemap_urls": ["/sitemap.xml"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(h, http.MethodPost, tt.path, tt.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d (body: %s)", rec.Code, http.StatusBadRequest, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), `"error"`) {
+				t.Errorf("body = %s, want error response", rec.Body)
+			}
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	extract := func(ctx context.Context, url string) (string, bool, error) {
+		once.Do(func() { close(started) })
+		<-release
+		return "本文", true, nil
+	}
+	h := New(nil, extract, Config{MaxConcurrent: 1}).Handler()
+
+	// 1件目のリクエストが処理中の間に、2件目のリクエストを送信する
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- doRequest(h, http.MethodPost, "/extract", `{"url": "https://example.com/a"}`)
+	}()
+	<-started
+
+	rec := doRequest(h, http.MethodPost, "/extract", `{"url": "https://example.com/b"}`)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want %q", got, "1")
+	}
+
+	close(release)
+	if first := <-done; first.Code != http.StatusOK {
+		t.Errorf("first request status = %d, want %d", first.Code, http.StatusOK)
+	}
+
+	// スロットが解放された後は受け付ける
+	if rec := doRequest(h, http.MethodPost, "/extract", `{"url": "https://example.com/c"}`); rec.Code != http.StatusOK {
+		t.Errorf("status after release = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	store, err := jobs.Open(filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatalf("jobs.Open() error = %v", err)
+	}
+	defer store.Close()
+
+	// ワーカーを起動しないため、投入したジョブは実行待ちのまま残る
+	s := New(nil, nil, Config{})
+	s.Jobs = jobs.NewQueue(store, nil, 1, 1)
+	h := s.Handler()
+
+	const body = `{"feed_urls": ["https://example.com/feed.xml"]}`
+	rec := doRequest(h, http.MethodPost, "/jobs", body)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /jobs status = %d, want %d (body: %s)", rec.Code, http.StatusAccepted, rec.Body)
+	}
+	var created JobResponse
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if got := rec.Header().Get("Location"); got != "/jobs/"+created.ID {
+		t.Errorf("Location = %q, want %q", got, "/jobs/"+created.ID)
+	}
+
+	rec = doRequest(h, http.MethodGet, "/jobs/"+created.ID, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"queued"`) {
+		t.Errorf("GET /jobs/{id} = %d %s, want 200 with queued status", rec.Code, rec.Body)
+	}
+
+	rec = doRequest(h, http.MethodPost, "/jobs", body)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST /jobs on full queue status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want %q", got, "60")
+	}
+
+	rec = doRequest(h, http.MethodPost, "/jobs", `{"feed_urls": ["https://example.com/feed.xml"], "priority": 1}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("POST /jobs with unknown field status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = doRequest(h, http.MethodGet, "/jobs/unknown", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /jobs/unknown status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
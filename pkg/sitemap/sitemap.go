@@ -0,0 +1,200 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxDepth は、サイトマップインデックスを辿る最大の深さです。
+	DefaultMaxDepth = 3
+	// DefaultMaxSitemaps は、1回の解析で取得するサイトマップファイルの最大数です。
+	DefaultMaxSitemaps = 50
+)
+
+// Fetcher は、指定URLのレスポンスボディを取得する機能の抽象化です。
+// httpkit.Client がこのインターフェースを満たします。
+type Fetcher interface {
+	FetchBytes(ctx context.Context, url string) ([]byte, error)
+}
+
+// URL は、サイトマップに記載された1ページ分の情報です。
+type URL struct {
+	Loc     string    // ページのURL
+	LastMod time.Time // 最終更新日時 (lastmod、または Google News の publication_date)。不明な場合はゼロ値
+	Title   string    // Google News サイトマップの記事タイトル (通常のサイトマップでは空)
+}
+
+// document は、urlset (通常/Google News サイトマップ) と sitemapindex の両方を受け取るための構造体です。
+type document struct {
+	XMLName  xml.Name
+	URLs     []urlEntry   `xml:"url"`
+	Sitemaps []sitemapRef `xml:"sitemap"`
+}
+
+type urlEntry struct {
+	Loc     string     `xml:"loc"`
+	LastMod string     `xml:"lastmod"`
+	News    *newsEntry `xml:"news"`
+}
+
+// newsEntry は Google News サイトマップの news:news 要素です。
+type newsEntry struct {
+	Title           string `xml:"title"`
+	PublicationDate string `xml:"publication_date"`
+}
+
+type sitemapRef struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// Parser は、サイトマップの取得と解析を行います。サイトマップインデックスは再帰的に辿ります。
+type Parser struct {
+	fetcher     Fetcher
+	MaxDepth    int
+	MaxSitemaps int
+}
+
+// NewParser は、Fetcher を注入して Parser を初期化します。
+func NewParser(fetcher Fetcher) *Parser {
+	return &Parser{
+		fetcher:     fetcher,
+		MaxDepth:    DefaultMaxDepth,
+		MaxSitemaps: DefaultMaxSitemaps,
+	}
+}
+
+// FetchAndParse は、サイトマップ (またはサイトマップインデックス) を取得・解析し、含まれるページのURLを返します。
+// since がゼロ値でない場合、lastmod が since より前のページと子サイトマップは除外します。
+// lastmod が記載されていないページは常に含めます。
+func (p *Parser) FetchAndParse(ctx context.Context, sitemapURL string, since time.Time) ([]URL, error) {
+	state := &crawlState{since: since, seen: make(map[string]bool)}
+	if err := p.crawl(ctx, sitemapURL, 0, state); err != nil {
+		return nil, err
+	}
+	return state.urls, nil
+}
+
+// crawlState は、サイトマップインデックスを辿る間の状態を保持します。
+type crawlState struct {
+	since   time.Time
+	fetched int
+	seen    map[string]bool
+	urls    []URL
+}
+
+func (p *Parser) crawl(ctx context.Context, sitemapURL string, depth int, state *crawlState) error {
+	if state.fetched >= p.MaxSitemaps {
+		slog.Warn("取得するサイトマップの上限に達したため、残りをスキップします", slog.String("sitemap_url", sitemapURL), slog.Int("max_sitemaps", p.MaxSitemaps))
+		return nil
+	}
+	state.fetched++
+
+	body, err := p.fetcher.FetchBytes(ctx, sitemapURL)
+	if err != nil {
+		return fmt.Errorf("サイトマップの取得エラー (URL: %s): %w", sitemapURL, err)
+	}
+
+	doc, err := parse(body)
+	if err != nil {
+		return fmt.Errorf("サイトマップの解析エラー (URL: %s): %w", sitemapURL, err)
+	}
+
+	for _, entry := range doc.URLs {
+		loc := strings.TrimSpace(entry.Loc)
+		if loc == "" || state.seen[loc] {
+			continue
+		}
+
+		u := URL{Loc: loc, LastMod: parseTime(entry.LastMod)}
+		if entry.News != nil {
+			u.Title = strings.TrimSpace(entry.News.Title)
+			if published := parseTime(entry.News.PublicationDate); !published.IsZero() {
+				u.LastMod = published
+			}
+		}
+		if isBefore(u.LastMod, state.since) {
+			continue
+		}
+
+		state.seen[loc] = true
+		state.urls = append(state.urls, u)
+	}
+
+	for _, ref := range doc.Sitemaps {
+		loc := strings.TrimSpace(ref.Loc)
+		if loc == "" || isBefore(parseTime(ref.LastMod), state.since) {
+			continue
+		}
+		if depth+1 > p.MaxDepth {
+			slog.Warn("サイトマップインデックスの深さの上限に達しました", slog.String("sitemap_url", loc), slog.Int("max_depth", p.MaxDepth))
+			continue
+		}
+		// 子サイトマップの失敗はログに記録し、他の子サイトマップの処理を続行する
+		if err := p.crawl(ctx, loc, depth+1, state); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			slog.Error("子サイトマップの処理エラーが発生しました", slog.String("sitemap_url", loc), slog.Any("error", err))
+		}
+	}
+	return nil
+}
+
+// parse は、サイトマップのXMLを解析します。gzip 圧縮されたサイトマップ (.xml.gz) も扱えます。
+func parse(body []byte) (*document, error) {
+	if len(body) >= 2 && body[0] == 0x1f && body[1] == 0x8b {
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("gzipの展開エラー: %w", err)
+		}
+		defer gz.Close()
+		if body, err = io.ReadAll(gz); err != nil {
+			return nil, fmt.Errorf("gzipの展開エラー: %w", err)
+		}
+	}
+
+	var doc document
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("XMLデコードエラー: %w", err)
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("サイトマップではないXMLです (ルート要素: %s)", doc.XMLName.Local)
+	}
+	return &doc, nil
+}
+
+// timeLayouts は、サイトマップの日時 (W3C Datetime) として受け付けるレイアウトです。
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseTime は W3C Datetime 形式の文字列を解析します。解析できない場合はゼロ値を返します。
+func parseTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// isBefore は、日時が判明しており、かつ since より前であるかを判定します。
+func isBefore(t, since time.Time) bool {
+	return !since.IsZero() && !t.IsZero() && t.Before(since)
+}
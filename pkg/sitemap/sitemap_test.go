@@ -0,0 +1,209 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// mapFetcher は、URLをキーとするレスポンスボディを返す Fetcher です。
+type mapFetcher struct {
+	bodies  map[string]string
+	fetched []string
+}
+
+func (f *mapFetcher) FetchBytes(_ context.Context, url string) ([]byte, error) {
+	f.fetched = append(f.fetched, url)
+	body, ok := f.bodies[url]
+	if !ok {
+		return nil, fmt.Errorf("not found: %s", url)
+	}
+	return []byte(body), nil
+}
+
+func urlset(entries ...string) string {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	for _, e := range entries {
+		b.WriteString(e)
+	}
+	b.WriteString(`</urlset>`)
+	return b.String()
+}
+
+func sitemapIndex(entries ...string) string {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	for _, e := range entries {
+		b.WriteString(e)
+	}
+	b.WriteString(`</sitemapindex>`)
+	return b.String()
+}
+
+func TestFetchAndParse(t *testing.T) {
+	bodies := map[string]string{
+		"https://example.com/sitemap.xml": sitemapIndex(
+			`<sitemap><loc>https://example.com/posts-index.xml</loc></sitemap>`,
+			`<sitemap><loc>https://example.com/old.xml</loc><lastmod>2023-01-01</lastmod></sitemap>`,
+			`<sitemap><loc>https://example.com/missing.xml</loc></sitemap>`,
+		),
+		// 入れ子のサイトマップインデックス
+		"https://example.com/posts-index.xml": sitemapIndex(
+			`<sitemap><loc> https://example.com/posts-1.xml </loc><lastmod>2025-02-01T00:00:00+09:00</lastmod></sitemap>`,
+			`<sitemap><loc>https://example.com/news.xml</loc></sitemap>`,
+		),
+		"https://example.com/posts-1.xml": urlset(
+			`<url><loc>https://example.com/a</loc><lastmod>2025-01-20</lastmod></url>`,
+			`<url><loc>https://example.com/b</loc><lastmod>2024-12-31T23:59:59Z</lastmod></url>`,
+			`<url><loc>https://example.com/c</loc></url>`,
+			`<url><loc>https://example.com/a</loc></url>`,
+		),
+		"https://example.com/news.xml": urlset(
+			`<url><loc>https://example.com/news/1</loc><lastmod>2020-01-01</lastmod>` +
+				`<news:news xmlns:news="http://www.google.com/schemas/sitemap-news/0.9"><news:title> 速報 </news:title>` +
+				`<news:publication_date>2025-01-15T10:00:00Z</news:publication_date></news:news></url>`,
+		),
+		"https://example.com/old.xml": urlset(`<url><loc>https://example.com/old</loc></url>`),
+	}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		want  []string
+	}{
+		{
+			name: "入れ子のサイトマップインデックスを辿り、重複を除く",
+			want: []string{"https://example.com/a", "https://example.com/b", "https://example.com/c", "https://example.com/news/1", "https://example.com/old"},
+		},
+		{
+			name:  "lastmod が since より前のページと子サイトマップを除く",
+			since: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:  []string{"https://example.com/a", "https://example.com/c", "https://example.com/news/1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &mapFetcher{bodies: bodies}
+			urls, err := NewParser(fetcher).FetchAndParse(context.Background(), "https://example.com/sitemap.xml", tt.since)
+			if err != nil {
+				t.Fatalf("FetchAndParse() error = %v", err)
+			}
+			var got []string
+			for _, u := range urls {
+				got = append(got, u.Loc)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FetchAndParse() = %v, want %v", got, tt.want)
+			}
+			if !tt.since.IsZero() && slices.Contains(fetcher.fetched, "https://example.com/old.xml") {
+				t.Error("old.xml was fetched although its lastmod is before since")
+			}
+		})
+	}
+}
+
+func TestFetchAndParseNews(t *testing.T) {
+	fetcher := &mapFetcher{bodies: map[string]string{
+		"https://example.com/news.xml": urlset(
+			`<url><loc>https://example.com/news/1</loc>` +
+				`<news:news xmlns:news="http://www.google.com/schemas/sitemap-news/0.9"><news:title> 速報 </news:title>` +
+				`<news:publication_date>2025-01-15T10:00:00Z</news:publication_date></news:news></url>`,
+		),
+	}}
+	urls, err := NewParser(fetcher).FetchAndParse(context.Background(), "https://example.com/news.xml", time.Time{})
+	if err != nil {
+		t.Fatalf("FetchAndParse() error = %v", err)
+	}
+	want := URL{Loc: "https://example.com/news/1", LastMod: time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC), Title: "速報"}
+	if len(urls) != 1 || urls[0].Loc != want.Loc || !urls[0].LastMod.Equal(want.LastMod) || urls[0].Title != want.Title {
+		t.Errorf("FetchAndParse() = %+v, want [%+v]", urls, want)
+	}
+}
+
+func TestFetchAndParseLimits(t *testing.T) {
+	fetcher := &mapFetcher{bodies: map[string]string{
+		"https://example.com/0.xml": sitemapIndex(`<sitemap><loc>https://example.com/1.xml</loc></sitemap>`),
+		"https://example.com/1.xml": sitemapIndex(`<sitemap><loc>https://example.com/2.xml</loc></sitemap>`),
+		"https://example.com/2.xml": urlset(`<url><loc>https://example.com/deep</loc></url>`),
+	}}
+	parser := NewParser(fetcher)
+	parser.MaxDepth = 1
+
+	urls, err := parser.FetchAndParse(context.Background(), "https://example.com/0.xml", time.Time{})
+	if err != nil {
+		t.Fatalf("FetchAndParse() error = %v", err)
+	}
+	if len(urls) != 0 || slices.Contains(fetcher.fetched, "https://example.com/2.xml") {
+		t.Errorf("FetchAndParse() = %v, fetched %v; want the depth limit to stop at 1.xml", urls, fetcher.fetched)
+	}
+}
+
+func TestFetchAndParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"XMLではない", "<html"},
+		{"サイトマップではないXML", `<rss version="2.0"><channel></channel></rss>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &mapFetcher{bodies: map[string]string{"https://example.com/sitemap.xml": tt.body}}
+			if _, err := NewParser(fetcher).FetchAndParse(context.Background(), "https://example.com/sitemap.xml", time.Time{}); err == nil {
+				t.Error("FetchAndParse() error = nil, want error")
+			}
+		})
+	}
+
+	// ルートのサイトマップを取得できない場合はエラーを返す
+	fetcher := &mapFetcher{}
+	_, err := NewParser(fetcher).FetchAndParse(context.Background(), "https://example.com/sitemap.xml", time.Time{})
+	if err == nil {
+		t.Error("FetchAndParse() error = nil, want fetch error")
+	}
+}
+
+func TestParseGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(urlset(`<url><loc>https://example.com/gz</loc></url>`)))
+	gz.Close()
+
+	doc, err := parse(buf.Bytes())
+	if err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+	if len(doc.URLs) != 1 || doc.URLs[0].Loc != "https://example.com/gz" {
+		t.Errorf("parse() = %+v", doc.URLs)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2025-01-02", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{" 2025-01-02T03:04:05Z ", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"2025-01-02T03:04:05.123+09:00", time.Date(2025, 1, 1, 18, 4, 5, 123_000_000, time.UTC)},
+		{"2025-01-02T03:04+09:00", time.Date(2025, 1, 1, 18, 4, 0, 0, time.UTC)},
+		{"2025-01-02T03:04:05", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"", time.Time{}},
+		{"yesterday", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseTime(tt.value); !got.Equal(tt.want) {
+				t.Errorf("parseTime(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
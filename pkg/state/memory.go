@@ -0,0 +1,52 @@
+package state
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore は、プロセス内で抽出済みURLを保持する状態ストアです。
+// 永続化は行わないため、watch モードなど単一プロセス内での重複抽出の回避に使用します。
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+	lastRun time.Time
+}
+
+// NewMemoryStore は、空の MemoryStore を初期化します。
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry)}
+}
+
+// IsExtracted は、URLが抽出済みとして記録されているかを判定します。URLは正規化して比較します。
+func (s *MemoryStore) IsExtracted(rawURL string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, found := s.entries[NormalizeURL(rawURL)]
+	return found, nil
+}
+
+// MarkExtracted は、記事を抽出済みとして記録します。
+func (s *MemoryStore) MarkExtracted(entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range entries {
+		s.entries[NormalizeURL(entry.URL)] = entry
+	}
+	return nil
+}
+
+// LastRun は、最後に記録された実行日時を返します。
+func (s *MemoryStore) LastRun() (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastRun, nil
+}
+
+// SetLastRun は、実行日時を記録します。
+func (s *MemoryStore) SetLastRun(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastRun = t
+	return nil
+}
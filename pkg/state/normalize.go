@@ -0,0 +1,49 @@
+package state
+
+import (
+	"net/url"
+	"strings"
+)
+
+// trackingParams は、URLの正規化時に取り除くトラッキング用のクエリパラメータです。
+// "utm_" で始まるパラメータもすべて取り除きます。
+var trackingParams = map[string]bool{
+	"fbclid": true,
+	"gclid":  true,
+	"yclid":  true,
+	"mc_cid": true,
+	"mc_eid": true,
+}
+
+// NormalizeURL は、同一記事のURLが同じキーになるよう正規化します。
+// スキームとホストの小文字化、デフォルトポートとフラグメントの除去、トラッキング用パラメータの除去、
+// クエリパラメータの並べ替えを行います。解析できないURLは前後の空白のみ取り除いて返します。
+func NormalizeURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "http" && strings.HasSuffix(u.Host, ":80")) || (u.Scheme == "https" && strings.HasSuffix(u.Host, ":443")) {
+		u.Host = u.Host[:strings.LastIndex(u.Host, ":")]
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(key, "utm_") || trackingParams[key] {
+			query.Del(key)
+		}
+	}
+	// url.Values.Encode はキー順に並べ替えるため、パラメータの順序の違いを吸収できる
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,192 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/feedcache"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	urlsBucket  = "urls"  // 正規化URLをキーとする抽出済み記事
+	metaBucket  = "meta"  // 最終実行日時などのメタデータ
+	feedsBucket = "feeds" // フィードURLをキーとする条件付きGETの検証子 (ETag / Last-Modified)
+
+	lastRunKey = "last_run"
+
+	openTimeout = 5 * time.Second // 他プロセスがロック中の場合に待機する最大時間
+)
+
+// Entry は、抽出済みとして記録された1記事分の情報です。
+type Entry struct {
+	URL         string    `json:"url"`
+	Title       string    `json:"title,omitempty"`
+	ExtractedAt time.Time `json:"extracted_at"`
+}
+
+// Store は、抽出済みURLを永続化する bbolt ベースの状態ストアです。
+type Store struct {
+	db *bolt.DB
+}
+
+// Open は、指定パスの状態ストアを開きます。ファイルが存在しない場合は作成します。
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("状態ストアのオープンエラー (%s): %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range []string{urlsBucket, metaBucket, feedsBucket} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("状態ストアの初期化エラー (%s): %w", path, err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close は、状態ストアを閉じます。
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// IsExtracted は、URLが抽出済みとして記録されているかを判定します。URLは正規化して比較します。
+func (s *Store) IsExtracted(rawURL string) (bool, error) {
+	key := []byte(NormalizeURL(rawURL))
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket([]byte(urlsBucket)).Get(key) != nil
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("状態ストアの読み込みエラー: %w", err)
+	}
+	return found, nil
+}
+
+// MarkExtracted は、記事を抽出済みとして記録します。同じURLが既に記録されている場合は上書きします。
+func (s *Store) MarkExtracted(entries []Entry) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(urlsBucket))
+		for _, entry := range entries {
+			value, err := json.Marshal(entry)
+			if err != nil {
+				return fmt.Errorf("JSONエンコードエラー (URL: %s): %w", entry.URL, err)
+			}
+			if err := bucket.Put([]byte(NormalizeURL(entry.URL)), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("状態ストアの書き込みエラー: %w", err)
+	}
+	return nil
+}
+
+// LastRun は、最後に記録された実行日時を返します。記録がない場合はゼロ値を返します。
+func (s *Store) LastRun() (time.Time, error) {
+	var lastRun time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket([]byte(metaBucket)).Get([]byte(lastRunKey))
+		if value == nil {
+			return nil
+		}
+		return lastRun.UnmarshalText(value)
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("最終実行日時の読み込みエラー: %w", err)
+	}
+	return lastRun, nil
+}
+
+// SetLastRun は、実行日時を記録します。
+func (s *Store) SetLastRun(t time.Time) error {
+	value, err := t.MarshalText()
+	if err != nil {
+		return fmt.Errorf("最終実行日時のエンコードエラー: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(metaBucket)).Put([]byte(lastRunKey), value)
+	})
+	if err != nil {
+		return fmt.Errorf("最終実行日時の書き込みエラー: %w", err)
+	}
+	return nil
+}
+
+// GetValidators は、フィードURLの検証子を返します。記録がない場合はゼロ値を返します。
+// feedcache.ValidatorStore インターフェースを満たします。
+func (s *Store) GetValidators(feedURL string) (feedcache.Validators, error) {
+	var validators feedcache.Validators
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket([]byte(feedsBucket)).Get([]byte(feedURL))
+		if value == nil {
+			return nil
+		}
+		return json.Unmarshal(value, &validators)
+	})
+	if err != nil {
+		return feedcache.Validators{}, fmt.Errorf("フィードの検証子の読み込みエラー: %w", err)
+	}
+	return validators, nil
+}
+
+// SetValidators は、フィードURLの検証子を記録します。
+func (s *Store) SetValidators(feedURL string, v feedcache.Validators) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("フィードの検証子のエンコードエラー: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(feedsBucket)).Put([]byte(feedURL), value)
+	})
+	if err != nil {
+		return fmt.Errorf("フィードの検証子の書き込みエラー: %w", err)
+	}
+	return nil
+}
+
+// Prune は、抽出日時が olderThan より前の記録を削除し、削除した件数を返します。
+func (s *Store) Prune(olderThan time.Time) (int, error) {
+	pruned := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(urlsBucket))
+
+		// 走査中の削除はカーソルの位置を狂わせるため、対象キーを収集してから削除する
+		var staleKeys [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(v, &entry); err != nil || entry.ExtractedAt.Before(olderThan) {
+				staleKeys = append(staleKeys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range staleKeys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(staleKeys)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("状態ストアの整理エラー: %w", err)
+	}
+	return pruned, nil
+}
@@ -0,0 +1,147 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/shouni/go-web-exact/v2/pkg/extract"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+
+	DefaultServiceName = "web-text-pipe"
+)
+
+// Config は、OTLP によるトレースのエクスポート設定を保持します。
+type Config struct {
+	Endpoint    string  // OTLP コレクターのエンドポイント (例: localhost:4317)。空の場合はトレースを無効にする
+	Protocol    string  // ProtocolGRPC または ProtocolHTTP
+	Insecure    bool    // TLS を使用せずに接続する (ローカルのコレクター向け)
+	ServiceName string  // service.name リソース属性
+	SampleRatio float64 // 記録するトレースの割合 (0〜1)
+}
+
+// Setup は、設定に従って OTLP エクスポーターを持つ TracerProvider を構築し、グローバルに登録します。
+// 戻り値の shutdown は、終了時に未送信のスパンを送信してエクスポーターを停止します。
+// Endpoint が空の場合は何も登録せず、何もしない shutdown を返します。
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := NewProvider(exporter, cfg.ServiceName, cfg.SampleRatio)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// NewProvider は、指定のエクスポーターにスパンを送信する TracerProvider を構築します。
+// テストではインメモリのエクスポーター (tracetest.NewInMemoryExporter) を渡して使用できます。
+func NewProvider(exporter sdktrace.SpanExporter, serviceName string, sampleRatio float64) (*sdktrace.TracerProvider, error) {
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("トレースのリソース属性の構築エラー: %w", err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	), nil
+}
+
+// newExporter は、プロトコルに応じた OTLP エクスポーターを生成します。
+// ヘッダーやタイムアウトなど、フラグで指定しない設定は OTEL_EXPORTER_OTLP_* 環境変数から読み込まれます。
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	// スキーム付きの値 (http://localhost:4318) はURLとして、それ以外は host:port として扱う
+	isURL := strings.Contains(cfg.Endpoint, "://")
+
+	switch cfg.Protocol {
+	case ProtocolGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if isURL {
+			opts = []otlptracegrpc.Option{otlptracegrpc.WithEndpointURL(cfg.Endpoint)}
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("OTLP (gRPC) エクスポーターの初期化エラー: %w", err)
+		}
+		return exporter, nil
+	case ProtocolHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if isURL {
+			opts = []otlptracehttp.Option{otlptracehttp.WithEndpointURL(cfg.Endpoint)}
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("OTLP (HTTP) エクスポーターの初期化エラー: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("未対応の OTLP プロトコルです: %s (%s または %s を指定してください)", cfg.Protocol, ProtocolGRPC, ProtocolHTTP)
+	}
+}
+
+// --- Fetcher の計測 ---
+
+// tracer は、HTTP取得のスパンを生成します。
+var tracer = otel.Tracer("github.com/shouni/web-text-pipe-go/pkg/tracing")
+
+// tracedFetcher は、extract.Fetcher による取得1回ごとにスパンを記録します。
+type tracedFetcher struct {
+	fetcher extract.Fetcher
+}
+
+// InstrumentFetcher は、取得1回ごとにスパンを記録する extract.Fetcher を返します。
+// 並列抽出では、このスパンが各URLの抽出の所要時間を表します。
+func InstrumentFetcher(fetcher extract.Fetcher) extract.Fetcher {
+	return &tracedFetcher{fetcher: fetcher}
+}
+
+func (f *tracedFetcher) FetchBytes(ctx context.Context, url string) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "Fetcher.FetchBytes",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("url.full", url)),
+	)
+	defer span.End()
+
+	body, err := f.fetcher.FetchBytes(ctx, url)
+	span.SetAttributes(attribute.Int("http.response.body.size", len(body)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return body, err
+}
@@ -0,0 +1,91 @@
+package urllist
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// StdinPath は、入力元として標準入力を表すパスです。
+const StdinPath = "-"
+
+// maxLineBytes は、1行あたりの最大バイト数です (JSONL 形式で長いタイトルを含む場合を考慮)。
+const maxLineBytes = 1024 * 1024
+
+// Item は、URLリストの1件分です。JSONL 形式の場合はタイトルを併せて指定できます。
+type Item struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+}
+
+// Validate は、URLが有効なスキームとホストを含むかを検証します。
+func Validate(rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("無効なURL形式です。有効なスキームとホストを含むURLを指定してください (URL: %s): %w", rawURL, err)
+	}
+	if parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return fmt.Errorf("無効なURL形式です。有効なスキームとホストを含むURLを指定してください (URL: %s)", rawURL)
+	}
+	return nil
+}
+
+// Load は、指定パスのファイル (StdinPath の場合は標準入力) からURLリストを読み込みます。
+func Load(path string) ([]Item, error) {
+	if path == StdinPath {
+		return Read(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("URLリストファイルのオープンエラー (%s): %w", path, err)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Read は、改行区切りのURL、または {"url": "...", "title": "..."} 形式の JSON Lines を読み込みます。
+// 空行と '#' で始まる行は無視し、重複したURLは最初の1件のみを残します。
+func Read(r io.Reader) ([]Item, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+
+	var items []Item
+	seen := make(map[string]bool)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		item := Item{URL: line}
+		if strings.HasPrefix(line, "{") {
+			item = Item{}
+			if err := json.Unmarshal([]byte(line), &item); err != nil {
+				return nil, fmt.Errorf("%d 行目のJSONデコードエラー: %w", lineNo, err)
+			}
+			item.URL = strings.TrimSpace(item.URL)
+		}
+
+		if err := Validate(item.URL); err != nil {
+			return nil, fmt.Errorf("%d 行目: %w", lineNo, err)
+		}
+		if seen[item.URL] {
+			continue
+		}
+		seen[item.URL] = true
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("URLリストの読み込みエラー: %w", err)
+	}
+	return items, nil
+}
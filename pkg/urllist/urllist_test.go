@@ -0,0 +1,96 @@
+package urllist
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Item
+	}{
+		{
+			name:  "改行区切りのURL",
+			input: "https://example.com/a\n\n# コメント\n  https://example.com/b  \r\n",
+			want:  []Item{{URL: "https://example.com/a"}, {URL: "https://example.com/b"}},
+		},
+		{
+			name:  "JSON Lines",
+			input: `{"url": "https://example.com/a", "title": "記事A"}` + "\n" + `{"url": " https://example.com/b "}` + "\n",
+			want:  []Item{{URL: "https://example.com/a", Title: "記事A"}, {URL: "https://example.com/b"}},
+		},
+		{
+			name:  "URLと JSON Lines の混在と重複",
+			input: "https://example.com/a\n" + `{"url": "https://example.com/a", "title": "重複"}` + "\n" + `{"url": "https://example.com/b", "title": "記事B"}` + "\nhttps://example.com/b\n",
+			want:  []Item{{URL: "https://example.com/a"}, {URL: "https://example.com/b", Title: "記事B"}},
+		},
+		{
+			name:  "空の入力",
+			input: "\n# only comments\n",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Read() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"無効なURL", "https://example.com/a\nexample.com/b\n", "2 行目"},
+		{"スキームのみのURL", "https://\n", "1 行目"},
+		{"不正なJSON", "# header\n{\"url\": \n", "2 行目のJSONデコードエラー"},
+		{"url のないJSON", `{"title": "タイトルのみ"}` + "\n", "1 行目"},
+		{"長すぎる行", strings.Repeat("a", maxLineBytes+1), "URLリストの読み込みエラー"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Read() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		wantErr bool
+	}{
+		{"有効なURL", "https://example.com/a", false},
+		{"スキームのないURL", "example.com/a", true},
+		{"ホストのないURL", "https:///a", true},
+		{"解析できないURL", "https://example.com/%zz", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.rawURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate(%q) error = %v, wantErr %v", tt.rawURL, err, tt.wantErr)
+			}
+			if err != nil && strings.Contains(err.Error(), "%!") {
+				t.Errorf("Validate(%q) error has a formatting directive: %v", tt.rawURL, err)
+			}
+		})
+	}
+}
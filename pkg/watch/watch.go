@@ -0,0 +1,187 @@
+package watch
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/shouni/web-text-pipe-go/pkg/runner"
+)
+
+const (
+	DefaultInterval        = 15 * time.Minute
+	DefaultJitter          = 0.1
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+// Target は、監視対象のフィードとそのポーリング間隔です。
+type Target struct {
+	FeedURL  string
+	Interval time.Duration // 0 の場合は Config.DefaultInterval を使用する
+}
+
+// Config は、Watcher の動作設定を保持します。
+type Config struct {
+	Targets         []Target
+	DefaultInterval time.Duration       // フィードごとの間隔が指定されていない場合のポーリング間隔
+	Jitter          float64             // ポーリング間隔に加える揺らぎの割合 (0.1 なら ±10%)
+	HonorFeedHints  bool                // フィードが宣言する更新間隔 (<ttl> / sy:updatePeriod) が長い場合はそれに従う
+	ShutdownTimeout time.Duration       // 停止シグナル受信後、実行中のスクレイピングの完了を待つ最大時間
+	RunnerConfig    runner.RunnerConfig // 各ポーリングで使用する設定のテンプレート (FeedURLs は Target から設定される)
+}
+
+// ResultHandler は、1回のポーリングの結果を受け取るコールバックです。
+// Watcher はハンドラの呼び出しを直列化するため、実装側で排他制御を行う必要はありません。
+type ResultHandler func(target Target, result *runner.RunnerResult, err error)
+
+// ItemHandler は、ポーリング中に記事の最終結果が確定するたびに呼び出されるコールバックです (res は本文を含みます)。
+// ResultHandler と同じく呼び出しは直列化されます。1回のポーリングの記事をすべて渡した後に ResultHandler が呼び出されます。
+type ItemHandler func(target Target, runnerResult *runner.RunnerResult, res runner.ScrapeResult)
+
+// Watcher は、単一の runner.Runner を再利用して複数のフィードを定期的にポーリングします。
+// 記事は Runner.ScrapeAndStream で取得し、確定した順に OnItem に渡します。
+type Watcher struct {
+	runner  *runner.Runner
+	config  Config
+	handler ResultHandler
+
+	// OnItem が設定されている場合、記事の最終結果が確定するたびに呼び出されます。
+	// ResultHandler に渡される RunnerResult.Results は本文を含まないため、本文の出力にはこちらを使用します。
+	OnItem ItemHandler
+
+	handlerMu sync.Mutex
+}
+
+// NewWatcher は、Runner と設定、結果ハンドラを注入して Watcher を初期化します。
+func NewWatcher(r *runner.Runner, config Config, handler ResultHandler) *Watcher {
+	if config.DefaultInterval <= 0 {
+		config.DefaultInterval = DefaultInterval
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	return &Watcher{
+		runner:  r,
+		config:  config,
+		handler: handler,
+	}
+}
+
+// Run は、ctx がキャンセルされるまで各フィードのポーリングを続けます。
+// ctx のキャンセル後は新たなポーリングを開始せず、実行中のスクレイピングを ShutdownTimeout まで待機してから、
+// その結果 (中断された場合は部分的な結果) をハンドラに渡して終了します。
+func (w *Watcher) Run(ctx context.Context) {
+	// 実行中のスクレイピングは停止シグナルで即座に中断せず、猶予時間の経過後にキャンセルする
+	scrapeCtx, cancelScrapes := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelScrapes()
+
+	go func() {
+		<-ctx.Done()
+		slog.Info("停止シグナルを受信しました。実行中のスクレイピングの完了を待機します。", slog.Duration("shutdown_timeout", w.config.ShutdownTimeout))
+		timer := time.NewTimer(w.config.ShutdownTimeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			slog.Warn("猶予時間が経過したため、実行中のスクレイピングを中断します。")
+			cancelScrapes()
+		case <-scrapeCtx.Done():
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, target := range w.config.Targets {
+		wg.Add(1)
+		go func(target Target) {
+			defer wg.Done()
+			w.poll(ctx, scrapeCtx, target)
+		}(target)
+	}
+	wg.Wait()
+
+	slog.Info("すべてのフィードの監視を終了しました。")
+}
+
+// poll は、1つのフィードを停止されるまで繰り返しポーリングします。
+func (w *Watcher) poll(ctx, scrapeCtx context.Context, target Target) {
+	interval := target.Interval
+	if interval <= 0 {
+		interval = w.config.DefaultInterval
+	}
+
+	// 全フィードが同時に取得を始めないよう、初回は揺らぎの範囲で開始をずらす
+	if !w.wait(ctx, time.Duration(rand.Float64()*w.config.Jitter*float64(interval))) {
+		return
+	}
+
+	var feedHint time.Duration
+	for {
+		config := w.config.RunnerConfig
+		config.FeedURLs = []string{target.FeedURL}
+
+		result, err := w.runner.ScrapeAndStream(scrapeCtx, config, func(runnerResult *runner.RunnerResult, res runner.ScrapeResult) {
+			w.handleItem(target, runnerResult, res)
+		})
+		w.handle(target, result, err)
+
+		if result != nil && len(result.Feeds) > 0 && result.Feeds[0].UpdateInterval > 0 {
+			feedHint = result.Feeds[0].UpdateInterval
+		}
+
+		next := interval
+		if w.config.HonorFeedHints && feedHint > next {
+			next = feedHint
+		}
+		next = w.jittered(next)
+
+		slog.Info("次のポーリングまで待機します", slog.String("feed_url", target.FeedURL), slog.Duration("delay", next))
+		if !w.wait(ctx, next) {
+			return
+		}
+	}
+}
+
+// handle は、結果ハンドラを直列化して呼び出します。
+func (w *Watcher) handle(target Target, result *runner.RunnerResult, err error) {
+	if w.handler == nil {
+		return
+	}
+	w.handlerMu.Lock()
+	defer w.handlerMu.Unlock()
+	w.handler(target, result, err)
+}
+
+// handleItem は、記事ごとのハンドラを直列化して呼び出します。
+func (w *Watcher) handleItem(target Target, runnerResult *runner.RunnerResult, res runner.ScrapeResult) {
+	if w.OnItem == nil {
+		return
+	}
+	w.handlerMu.Lock()
+	defer w.handlerMu.Unlock()
+	w.OnItem(target, runnerResult, res)
+}
+
+// jittered は、間隔に ±Jitter の揺らぎを加えた値を返します。
+func (w *Watcher) jittered(d time.Duration) time.Duration {
+	if w.config.Jitter <= 0 {
+		return d
+	}
+	factor := 1 + w.config.Jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(d) * factor)
+}
+
+// wait は、指定時間だけ待機します。待機中に ctx がキャンセルされた場合は false を返します。
+func (w *Watcher) wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}